@@ -0,0 +1,163 @@
+// Package analyzer checks parsed programs for likely mistakes that are not
+// syntax errors.
+package analyzer
+
+import (
+	"fmt"
+	"github.com/slinky55/milo/ast"
+	"github.com/slinky55/milo/token"
+	"strings"
+)
+
+// Analyzer collects warnings about a program. Warnings never stop a
+// program from running.
+type Analyzer struct {
+	Warnings []string
+
+	// enums maps the enums declared in the program to their variants.
+	enums map[string][]string
+}
+
+func New() *Analyzer {
+	return &Analyzer{}
+}
+
+func (a *Analyzer) Analyze(program *ast.Program) {
+	a.enums = map[string][]string{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if stmt, ok := node.(*ast.EnumStatement); ok {
+			var variants []string
+			for _, variant := range stmt.Variants {
+				variants = append(variants, variant.Name.Value)
+			}
+			a.enums[stmt.Name.Value] = variants
+		}
+		return true
+	})
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if expr, ok := node.(*ast.MatchExpr); ok {
+			a.checkMatch(expr)
+		}
+		return true
+	})
+}
+
+// checkMatch warns about a match that has no wildcard arm and handles only
+// some of the values of a boolean or variants of an enum.
+func (a *Analyzer) checkMatch(expr *ast.MatchExpr) {
+	a.checkBooleans(expr)
+	a.checkVariants(expr)
+}
+
+// checkBooleans warns about a match on booleans that handles only one of
+// true and false.
+func (a *Analyzer) checkBooleans(expr *ast.MatchExpr) {
+	seen := map[bool]bool{}
+
+	for _, arm := range expr.Arms {
+		if !booleans(arm.Pattern, seen) {
+			return
+		}
+	}
+
+	switch {
+	case len(seen) == 0 || len(seen) == 2:
+		return
+	case seen[true]:
+		a.warn(expr.Token, "non-exhaustive match: missing false")
+	default:
+		a.warn(expr.Token, "non-exhaustive match: missing true")
+	}
+}
+
+// booleans records the boolean literals matched by pattern in seen. It
+// returns false if pattern may match anything else, including wildcards.
+func booleans(node ast.Pattern, seen map[bool]bool) bool {
+	switch pattern := node.(type) {
+	case *ast.LiteralPattern:
+		b, ok := pattern.Value.(*ast.BooleanExpr)
+		if ok {
+			seen[b.Value] = true
+		}
+		return ok
+	case *ast.OrPattern:
+		for _, alt := range pattern.Alternatives {
+			if !booleans(alt, seen) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// checkVariants warns about a match on the variants of an enum that does
+// not handle them all.
+func (a *Analyzer) checkVariants(expr *ast.MatchExpr) {
+	enum := ""
+	seen := map[string]bool{}
+
+	for _, arm := range expr.Arms {
+		if !variants(arm.Pattern, &enum, seen) {
+			return
+		}
+	}
+
+	declared, ok := a.enums[enum]
+	if !ok {
+		return
+	}
+
+	var missing []string
+	for _, variant := range declared {
+		if !seen[variant] {
+			missing = append(missing, enum+"."+variant)
+		}
+	}
+
+	if len(missing) > 0 {
+		a.warn(expr.Token, "non-exhaustive match: missing %s", strings.Join(missing, ", "))
+	}
+}
+
+// variants records in seen the variants of enum that pattern matches
+// whatever their fields hold, setting enum if it is empty. It returns false
+// if pattern may match anything other than variants of enum.
+func variants(node ast.Pattern, enum *string, seen map[string]bool) bool {
+	switch pattern := node.(type) {
+	case *ast.VariantPattern:
+		if *enum == "" {
+			*enum = pattern.Enum.Value
+		}
+		if pattern.Enum.Value != *enum {
+			return false
+		}
+
+		for _, arg := range pattern.Args {
+			switch arg.(type) {
+			case *ast.IdentExpr, *ast.WildcardPattern:
+			default:
+				// a variant only partly matched still needs another arm
+				return true
+			}
+		}
+		seen[pattern.Variant.Value] = true
+		return true
+	case *ast.OrPattern:
+		for _, alt := range pattern.Alternatives {
+			if !variants(alt, enum, seen) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func (a *Analyzer) warn(t *token.Token, msg string, args ...any) {
+	warning := fmt.Sprintf("analyzer warning: %d:%d: ", t.Line, t.Column) + fmt.Sprintf(msg, args...)
+	a.Warnings = append(a.Warnings, warning)
+}
@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"github.com/slinky55/milo/lexer"
+	"github.com/slinky55/milo/parser"
+	"testing"
+)
+
+func analyze(t *testing.T, input string) []string {
+	l := lexer.New(input)
+	p := parser.New(l)
+
+	program := p.Parse()
+	if len(p.Errors) > 0 {
+		t.Fatalf("parser had errors: %v", p.Errors)
+	}
+
+	a := New()
+	a.Analyze(program)
+	return a.Warnings
+}
+
+func TestBooleanMatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"match (x) { true => 1, false => 0 }", nil},
+		{"match (x) { true | false => 1 }", nil},
+		{"match (x) { true => 1, _ => 0 }", nil},
+		{"match (x) { 1 => 1, 2 => 2 }", nil},
+		{"match (x) { true => 1 }", []string{"analyzer warning: 1:1: non-exhaustive match: missing false"}},
+		{"let f = fn(x) { match (x) { false => 0 } };", []string{"analyzer warning: 1:17: non-exhaustive match: missing true"}},
+	}
+
+	for _, test := range tests {
+		warnings := analyze(t, test.input)
+
+		if len(warnings) != len(test.expected) {
+			t.Errorf("%s: expected warnings %v, found %v", test.input, test.expected, warnings)
+			continue
+		}
+
+		for i, w := range warnings {
+			if w != test.expected[i] {
+				t.Errorf("%s: expected warning %q, found %q", test.input, test.expected[i], w)
+			}
+		}
+	}
+}
+
+func TestVariantMatch(t *testing.T) {
+	prelude := "enum Shape { Circle(r), Rect(w, h), Empty }\n"
+
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"match (s) { Shape.Circle(r) => r, Shape.Rect(w, _) => w, Shape.Empty => 0 }", nil},
+		{"match (s) { Shape.Circle | Shape.Rect => 1, Shape.Empty => 0 }", nil},
+		{"match (s) { Shape.Circle(r) => r, _ => 0 }", nil},
+		{"match (s) { Other.A => 1 }", nil},
+		{"match (s) { Shape.Circle(r) => r }", []string{"analyzer warning: 2:1: non-exhaustive match: missing Shape.Rect, Shape.Empty"}},
+		{"match (s) { Shape.Circle(1) => 1, Shape.Rect(w, h) => w, Shape.Empty => 0 }", []string{"analyzer warning: 2:1: non-exhaustive match: missing Shape.Circle"}},
+		{"let f = fn(s) { match (s) { Shape.Empty => 0 } };", []string{"analyzer warning: 2:17: non-exhaustive match: missing Shape.Circle, Shape.Rect"}},
+	}
+
+	for _, test := range tests {
+		warnings := analyze(t, prelude+test.input)
+
+		if len(warnings) != len(test.expected) {
+			t.Errorf("%s: expected warnings %v, found %v", test.input, test.expected, warnings)
+			continue
+		}
+
+		for i, w := range warnings {
+			if w != test.expected[i] {
+				t.Errorf("%s: expected warning %q, found %q", test.input, test.expected[i], w)
+			}
+		}
+	}
+}
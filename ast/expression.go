@@ -24,6 +24,7 @@ func (ie *IdentExpr) ToString() string {
 }
 
 func (ie *IdentExpr) expressionNode() { /* EMPTY */ }
+func (ie *IdentExpr) patternNode()    { /* EMPTY */ }
 
 type NumberExpr struct {
 	Token *token.Token
@@ -144,9 +145,46 @@ func (ie *IfExpr) ToString() string {
 
 func (ie *IfExpr) expressionNode() { /* EMPTY */ }
 
+// TryExpr, written try { } catch (e) { } finally { }, evaluates to the
+// value of its body, or of the catch block if the body raised an error.
+// Either the catch or the finally block may be left out.
+type TryExpr struct {
+	Token   *token.Token
+	Body    *StatementBlock
+	Param   *IdentExpr
+	Catch   *StatementBlock
+	Finally *StatementBlock
+}
+
+func (te *TryExpr) Literal() string {
+	return te.Token.Literal
+}
+
+func (te *TryExpr) ToString() string {
+	var out strings.Builder
+	out.WriteString("try " + te.Body.ToString())
+
+	if te.Catch != nil {
+		out.WriteString(" catch (" + te.Param.ToString() + ") " + te.Catch.ToString())
+	}
+
+	if te.Finally != nil {
+		out.WriteString(" finally " + te.Finally.ToString())
+	}
+
+	return out.String()
+}
+
+func (te *TryExpr) expressionNode() { /* EMPTY */ }
+
 type FunctionExpr struct {
-	Token      *token.Token
-	Parameters []*IdentExpr
+	Token *token.Token
+
+	// Name is the name the function was bound to by let, if any. It is
+	// used in error messages.
+	Name string
+
+	Parameters []Pattern
 	Body       *StatementBlock
 }
 
@@ -177,6 +215,10 @@ type CallExpr struct {
 	Token     *token.Token
 	Function  Expression
 	Arguments []Expression
+
+	// Named are the arguments passed by parameter name, which always
+	// follow the positional ones.
+	Named []*NamedArg
 }
 
 func (ce *CallExpr) Literal() string {
@@ -190,6 +232,9 @@ func (ce *CallExpr) ToString() string {
 	for _, a := range ce.Arguments {
 		args = append(args, a.ToString())
 	}
+	for _, a := range ce.Named {
+		args = append(args, a.ToString())
+	}
 
 	out.WriteString(ce.Function.ToString())
 	out.WriteString("(")
@@ -200,3 +245,214 @@ func (ce *CallExpr) ToString() string {
 }
 
 func (ce *CallExpr) expressionNode() { /* EMPTY */ }
+
+// PipeExpr, written left |> right, calls right with the value of left. If
+// right is a call, left is passed before its other arguments.
+type PipeExpr struct {
+	Token *token.Token
+	Left  Expression
+	Right Expression
+}
+
+func (pe *PipeExpr) Literal() string {
+	return pe.Token.Literal
+}
+
+func (pe *PipeExpr) ToString() string {
+	return "(" + pe.Left.ToString() + " |> " + pe.Right.ToString() + ")"
+}
+
+func (pe *PipeExpr) expressionNode() { /* EMPTY */ }
+
+// PropagateExpr, written expr?, unwraps the Ok result expr or returns the
+// Err result from the enclosing function. An error value counts as Err.
+type PropagateExpr struct {
+	Token *token.Token
+	Expr  Expression
+}
+
+func (pe *PropagateExpr) Literal() string {
+	return pe.Token.Literal
+}
+
+func (pe *PropagateExpr) ToString() string {
+	return "(" + pe.Expr.ToString() + "?)"
+}
+
+func (pe *PropagateExpr) expressionNode() { /* EMPTY */ }
+
+// NamedArg is an argument passed by parameter name, as in f(y: 2).
+type NamedArg struct {
+	Token *token.Token
+	Name  *IdentExpr
+	Value Expression
+}
+
+func (na *NamedArg) Literal() string {
+	return na.Token.Literal
+}
+
+func (na *NamedArg) ToString() string {
+	return na.Name.ToString() + ": " + na.Value.ToString()
+}
+
+type NullExpr struct {
+	Token *token.Token
+}
+
+func (ne *NullExpr) Literal() string {
+	return ne.Token.Literal
+}
+
+func (ne *NullExpr) ToString() string {
+	return ne.Literal()
+}
+
+func (ne *NullExpr) expressionNode() { /* EMPTY */ }
+
+type ArrayExpr struct {
+	Token    *token.Token
+	Elements []Expression
+}
+
+func (ae *ArrayExpr) Literal() string {
+	return ae.Token.Literal
+}
+
+func (ae *ArrayExpr) ToString() string {
+	var elems []string
+	for _, el := range ae.Elements {
+		elems = append(elems, el.ToString())
+	}
+
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+func (ae *ArrayExpr) expressionNode() { /* EMPTY */ }
+
+// MapExpr is a map literal. Keys and Values are parallel slices kept in
+// source order.
+type MapExpr struct {
+	Token  *token.Token
+	Keys   []Expression
+	Values []Expression
+}
+
+func (me *MapExpr) Literal() string {
+	return me.Token.Literal
+}
+
+func (me *MapExpr) ToString() string {
+	var pairs []string
+	for i, key := range me.Keys {
+		pairs = append(pairs, key.ToString()+": "+me.Values[i].ToString())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func (me *MapExpr) expressionNode() { /* EMPTY */ }
+
+// IndexExpr is an index access, left[index], or left?.[index] when
+// Optional is set.
+type IndexExpr struct {
+	Token    *token.Token
+	Left     Expression
+	Index    Expression
+	Optional bool
+}
+
+func (ie *IndexExpr) Literal() string {
+	return ie.Token.Literal
+}
+
+func (ie *IndexExpr) ToString() string {
+	var out strings.Builder
+
+	out.WriteString(ie.Left.ToString())
+	if ie.Optional {
+		out.WriteString("?.")
+	}
+	out.WriteString("[" + ie.Index.ToString() + "]")
+
+	return out.String()
+}
+
+func (ie *IndexExpr) expressionNode() { /* EMPTY */ }
+
+// MemberExpr is a member access, object.property, or object?.property when
+// Optional is set.
+type MemberExpr struct {
+	Token    *token.Token
+	Object   Expression
+	Property *IdentExpr
+	Optional bool
+}
+
+func (me *MemberExpr) Literal() string {
+	return me.Token.Literal
+}
+
+func (me *MemberExpr) ToString() string {
+	return me.Object.ToString() + me.Literal() + me.Property.ToString()
+}
+
+func (me *MemberExpr) expressionNode() { /* EMPTY */ }
+
+type TernaryExpr struct {
+	Token       *token.Token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (te *TernaryExpr) Literal() string {
+	return te.Token.Literal
+}
+
+func (te *TernaryExpr) ToString() string {
+	return "(" + te.Condition.ToString() + " ? " + te.Consequence.ToString() + " : " + te.Alternative.ToString() + ")"
+}
+
+func (te *TernaryExpr) expressionNode() { /* EMPTY */ }
+
+type MatchExpr struct {
+	Token   *token.Token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpr) Literal() string {
+	return me.Token.Literal
+}
+
+func (me *MatchExpr) ToString() string {
+	var arms []string
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.ToString())
+	}
+
+	return "match (" + me.Subject.ToString() + ") { " + strings.Join(arms, ", ") + " }"
+}
+
+func (me *MatchExpr) expressionNode() { /* EMPTY */ }
+
+// MatchArm is one arm of a match expression. Its body is either a single
+// expression or a block; the other field is nil.
+type MatchArm struct {
+	Token   *token.Token
+	Pattern Pattern
+	Expr    Expression
+	Block   *StatementBlock
+}
+
+func (ma *MatchArm) Literal() string {
+	return ma.Token.Literal
+}
+
+func (ma *MatchArm) ToString() string {
+	if ma.Block != nil {
+		return ma.Pattern.ToString() + " => " + ma.Block.ToString()
+	}
+	return ma.Pattern.ToString() + " => " + ma.Expr.ToString()
+}
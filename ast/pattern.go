@@ -0,0 +1,203 @@
+package ast
+
+import (
+	"github.com/slinky55/milo/token"
+	"strings"
+)
+
+// Pattern is the left hand side of a match arm, or the target of a let
+// binding or function parameter. An identifier is a pattern binding the
+// whole value to its name.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// WildcardPattern, written _, matches every value.
+type WildcardPattern struct {
+	Token *token.Token
+}
+
+func (wp *WildcardPattern) Literal() string {
+	return wp.Token.Literal
+}
+
+func (wp *WildcardPattern) ToString() string {
+	return wp.Literal()
+}
+
+func (wp *WildcardPattern) patternNode() { /* EMPTY */ }
+
+// LiteralPattern matches values equal to a literal.
+type LiteralPattern struct {
+	Token *token.Token
+	Value Expression
+}
+
+func (lp *LiteralPattern) Literal() string {
+	return lp.Token.Literal
+}
+
+func (lp *LiteralPattern) ToString() string {
+	return lp.Value.ToString()
+}
+
+func (lp *LiteralPattern) patternNode() { /* EMPTY */ }
+
+// RangePattern, written low..high, matches numbers between low and high
+// inclusive.
+type RangePattern struct {
+	Token *token.Token
+	Low   Expression
+	High  Expression
+}
+
+func (rp *RangePattern) Literal() string {
+	return rp.Token.Literal
+}
+
+func (rp *RangePattern) ToString() string {
+	return rp.Low.ToString() + ".." + rp.High.ToString()
+}
+
+func (rp *RangePattern) patternNode() { /* EMPTY */ }
+
+// OrPattern, written a | b, matches values matching any alternative.
+type OrPattern struct {
+	Token        *token.Token
+	Alternatives []Pattern
+}
+
+func (op *OrPattern) Literal() string {
+	return op.Token.Literal
+}
+
+func (op *OrPattern) ToString() string {
+	var alts []string
+	for _, alt := range op.Alternatives {
+		alts = append(alts, alt.ToString())
+	}
+	return strings.Join(alts, " | ")
+}
+
+func (op *OrPattern) patternNode() { /* EMPTY */ }
+
+// ArrayPattern, written [a, b, ...rest], destructures an array by position.
+// Only the last element may be a RestPattern.
+type ArrayPattern struct {
+	Token    *token.Token
+	Elements []Pattern
+}
+
+func (ap *ArrayPattern) Literal() string {
+	return ap.Token.Literal
+}
+
+func (ap *ArrayPattern) ToString() string {
+	var elements []string
+	for _, el := range ap.Elements {
+		elements = append(elements, el.ToString())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+func (ap *ArrayPattern) patternNode() { /* EMPTY */ }
+
+// MapPattern, written {name, age: years}, destructures a map by key. A key
+// without a pattern binds the value to a variable of the same name.
+type MapPattern struct {
+	Token  *token.Token
+	Keys   []*IdentExpr
+	Values []Pattern
+}
+
+func (mp *MapPattern) Literal() string {
+	return mp.Token.Literal
+}
+
+func (mp *MapPattern) ToString() string {
+	var entries []string
+	for i, key := range mp.Keys {
+		entries = append(entries, mp.entry(key, mp.Values[i]))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// entry renders one key, omitting the pattern if it is the key itself.
+func (mp *MapPattern) entry(key *IdentExpr, value Pattern) string {
+	target := value
+	if dp, ok := value.(*DefaultPattern); ok {
+		target = dp.Target
+	}
+
+	if ident, ok := target.(*IdentExpr); ok && ident.Value == key.Value {
+		return value.ToString()
+	}
+	return key.ToString() + ": " + value.ToString()
+}
+
+func (mp *MapPattern) patternNode() { /* EMPTY */ }
+
+// RestPattern, written ...name, binds the remaining elements of an array.
+type RestPattern struct {
+	Token *token.Token
+	Ident *IdentExpr
+}
+
+func (rp *RestPattern) Literal() string {
+	return rp.Token.Literal
+}
+
+func (rp *RestPattern) ToString() string {
+	return rp.Literal() + rp.Ident.ToString()
+}
+
+func (rp *RestPattern) patternNode() { /* EMPTY */ }
+
+// DefaultPattern, written target = default, binds the default value when
+// the destructured value is missing or null.
+type DefaultPattern struct {
+	Token   *token.Token
+	Target  Pattern
+	Default Expression
+}
+
+func (dp *DefaultPattern) Literal() string {
+	return dp.Token.Literal
+}
+
+func (dp *DefaultPattern) ToString() string {
+	return dp.Target.ToString() + " = " + dp.Default.ToString()
+}
+
+func (dp *DefaultPattern) patternNode() { /* EMPTY */ }
+
+// VariantPattern, written Enum.Variant(a, b), matches values of a variant
+// of an enum and matches their fields against Args, where identifiers bind
+// the field's value. Without parentheses, Args is nil and any value of the
+// variant matches.
+type VariantPattern struct {
+	Token   *token.Token
+	Enum    *IdentExpr
+	Variant *IdentExpr
+	Args    []Pattern
+}
+
+func (vp *VariantPattern) Literal() string {
+	return vp.Token.Literal
+}
+
+func (vp *VariantPattern) ToString() string {
+	name := vp.Enum.ToString() + "." + vp.Variant.ToString()
+	if vp.Args == nil {
+		return name
+	}
+
+	var args []string
+	for _, arg := range vp.Args {
+		args = append(args, arg.ToString())
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (vp *VariantPattern) patternNode() { /* EMPTY */ }
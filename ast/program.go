@@ -4,6 +4,10 @@ import "strings"
 
 type Program struct {
 	Statements []Statement
+
+	// File is the path of the source file the program was parsed from,
+	// or empty if it did not come from a file.
+	File string
 }
 
 func (p *Program) Literal() string {
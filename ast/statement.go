@@ -2,6 +2,7 @@ package ast
 
 import (
 	"github.com/slinky55/milo/token"
+	"strconv"
 	"strings"
 )
 
@@ -11,9 +12,9 @@ type Statement interface {
 }
 
 type LetStatement struct {
-	Token *token.Token
-	Ident *IdentExpr
-	Expr  Expression
+	Token   *token.Token
+	Pattern Pattern
+	Expr    Expression
 }
 
 func (ls *LetStatement) Literal() string {
@@ -24,7 +25,7 @@ func (ls *LetStatement) ToString() string {
 	var out strings.Builder
 
 	out.WriteString(ls.Literal())
-	out.WriteString(" " + ls.Ident.ToString() + " ")
+	out.WriteString(" " + ls.Pattern.ToString() + " ")
 	out.WriteString("= ")
 
 	if ls.Expr != nil {
@@ -98,3 +99,170 @@ func (es *StatementBlock) ToString() string {
 }
 
 func (es *StatementBlock) statementNode() { /* EMPTY */ }
+
+// ImportStatement, written import "path" as name;, binds the exports of
+// the module at path to name.
+type ImportStatement struct {
+	Token *token.Token
+	Path  *StringExpr
+	Alias *IdentExpr
+}
+
+func (is *ImportStatement) Literal() string {
+	return is.Token.Literal
+}
+
+func (is *ImportStatement) ToString() string {
+	return is.Literal() + " " + strconv.Quote(is.Path.Value) + " as " + is.Alias.ToString() + ";"
+}
+
+func (is *ImportStatement) statementNode() { /* EMPTY */ }
+
+// ExportStatement, written export let ...;, makes the variables bound by a
+// let statement visible to modules importing this one.
+type ExportStatement struct {
+	Token *token.Token
+	Let   *LetStatement
+}
+
+func (es *ExportStatement) Literal() string {
+	return es.Token.Literal
+}
+
+func (es *ExportStatement) ToString() string {
+	return es.Literal() + " " + es.Let.ToString()
+}
+
+func (es *ExportStatement) statementNode() { /* EMPTY */ }
+
+// ThrowStatement, written throw expr;, raises the error or message expr,
+// which unwinds to the nearest enclosing try.
+type ThrowStatement struct {
+	Token *token.Token
+	Expr  Expression
+}
+
+func (ts *ThrowStatement) Literal() string {
+	return ts.Token.Literal
+}
+
+func (ts *ThrowStatement) ToString() string {
+	return ts.Literal() + " " + ts.Expr.ToString() + ";"
+}
+
+func (ts *ThrowStatement) statementNode() { /* EMPTY */ }
+
+// StructStatement, written struct Name { field, ... }, declares a record
+// type with the named fields and binds its constructor to Name.
+type StructStatement struct {
+	Token  *token.Token
+	Name   *IdentExpr
+	Fields []*IdentExpr
+}
+
+func (ss *StructStatement) Literal() string {
+	return ss.Token.Literal
+}
+
+func (ss *StructStatement) ToString() string {
+	fields := make([]string, len(ss.Fields))
+	for i, field := range ss.Fields {
+		fields[i] = field.ToString()
+	}
+	return ss.Literal() + " " + ss.Name.ToString() + " { " + strings.Join(fields, ", ") + " }"
+}
+
+func (ss *StructStatement) statementNode() { /* EMPTY */ }
+
+// AssignStatement, written target.field = value;, replaces the value of a
+// field of a struct instance.
+type AssignStatement struct {
+	Token  *token.Token
+	Target *MemberExpr
+	Value  Expression
+}
+
+func (as *AssignStatement) Literal() string {
+	return as.Token.Literal
+}
+
+func (as *AssignStatement) ToString() string {
+	return as.Target.ToString() + " = " + as.Value.ToString() + ";"
+}
+
+func (as *AssignStatement) statementNode() { /* EMPTY */ }
+
+// ImplStatement, written impl Type { fn name(self, ...) { ... } ... },
+// defines methods on the struct Type. Each method receives the instance it
+// is called on as its first parameter, self.
+type ImplStatement struct {
+	Token   *token.Token
+	Type    *IdentExpr
+	Methods []*FunctionExpr
+}
+
+func (is *ImplStatement) Literal() string {
+	return is.Token.Literal
+}
+
+func (is *ImplStatement) ToString() string {
+	var out strings.Builder
+
+	out.WriteString(is.Literal() + " " + is.Type.ToString() + " { ")
+	for _, method := range is.Methods {
+		var params []string
+		for _, param := range method.Parameters {
+			params = append(params, param.ToString())
+		}
+
+		out.WriteString(method.Literal() + " " + method.Name + "(" + strings.Join(params, ", ") + ") ")
+		out.WriteString(method.Body.ToString() + " ")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (is *ImplStatement) statementNode() { /* EMPTY */ }
+
+// EnumStatement, written enum Name { Variant(field, ...), Other, ... },
+// declares a tagged union and binds it to Name. Variants with fields are
+// constructed by calling Name.Variant; those without are values.
+type EnumStatement struct {
+	Token    *token.Token
+	Name     *IdentExpr
+	Variants []*EnumVariant
+}
+
+func (es *EnumStatement) Literal() string {
+	return es.Token.Literal
+}
+
+func (es *EnumStatement) ToString() string {
+	variants := make([]string, len(es.Variants))
+	for i, variant := range es.Variants {
+		variants[i] = variant.ToString()
+	}
+	return es.Literal() + " " + es.Name.ToString() + " { " + strings.Join(variants, ", ") + " }"
+}
+
+func (es *EnumStatement) statementNode() { /* EMPTY */ }
+
+// EnumVariant is one variant of an enum. Fields is nil for a variant
+// written without parentheses.
+type EnumVariant struct {
+	Name   *IdentExpr
+	Fields []*IdentExpr
+}
+
+func (ev *EnumVariant) ToString() string {
+	if ev.Fields == nil {
+		return ev.Name.ToString()
+	}
+
+	fields := make([]string, len(ev.Fields))
+	for i, field := range ev.Fields {
+		fields[i] = field.ToString()
+	}
+	return ev.Name.ToString() + "(" + strings.Join(fields, ", ") + ")"
+}
@@ -0,0 +1,157 @@
+package ast
+
+// Inspect traverses the tree rooted at node in depth-first order, calling
+// f for each node. If f returns false the children of that node are
+// skipped.
+func Inspect(node Node, f func(Node) bool) {
+	if !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+	case *StatementBlock:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+	case *LetStatement:
+		Inspect(n.Pattern, f)
+		Inspect(n.Expr, f)
+	case *ImportStatement:
+		Inspect(n.Path, f)
+		Inspect(n.Alias, f)
+	case *ExportStatement:
+		Inspect(n.Let, f)
+	case *ReturnStatement:
+		Inspect(n.Expr, f)
+	case *ThrowStatement:
+		Inspect(n.Expr, f)
+	case *StructStatement:
+		Inspect(n.Name, f)
+		for _, field := range n.Fields {
+			Inspect(field, f)
+		}
+	case *EnumStatement:
+		Inspect(n.Name, f)
+		for _, variant := range n.Variants {
+			Inspect(variant.Name, f)
+			for _, field := range variant.Fields {
+				Inspect(field, f)
+			}
+		}
+	case *ImplStatement:
+		Inspect(n.Type, f)
+		for _, method := range n.Methods {
+			Inspect(method, f)
+		}
+	case *AssignStatement:
+		Inspect(n.Target, f)
+		Inspect(n.Value, f)
+	case *ExpressionStatement:
+		Inspect(n.Expr, f)
+	case *PrefixExpression:
+		Inspect(n.Right, f)
+	case *BinaryExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+	case *IfExpr:
+		Inspect(n.Condition, f)
+		Inspect(n.Consequence, f)
+		if n.Alternative != nil {
+			Inspect(n.Alternative, f)
+		}
+	case *TryExpr:
+		Inspect(n.Body, f)
+		if n.Catch != nil {
+			Inspect(n.Param, f)
+			Inspect(n.Catch, f)
+		}
+		if n.Finally != nil {
+			Inspect(n.Finally, f)
+		}
+	case *TernaryExpr:
+		Inspect(n.Condition, f)
+		Inspect(n.Consequence, f)
+		Inspect(n.Alternative, f)
+	case *FunctionExpr:
+		for _, param := range n.Parameters {
+			Inspect(param, f)
+		}
+		Inspect(n.Body, f)
+	case *CallExpr:
+		Inspect(n.Function, f)
+		for _, arg := range n.Arguments {
+			Inspect(arg, f)
+		}
+		for _, arg := range n.Named {
+			Inspect(arg, f)
+		}
+	case *PipeExpr:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+	case *PropagateExpr:
+		Inspect(n.Expr, f)
+	case *NamedArg:
+		Inspect(n.Name, f)
+		Inspect(n.Value, f)
+	case *ArrayExpr:
+		for _, el := range n.Elements {
+			Inspect(el, f)
+		}
+	case *MapExpr:
+		for i := range n.Keys {
+			Inspect(n.Keys[i], f)
+			Inspect(n.Values[i], f)
+		}
+	case *IndexExpr:
+		Inspect(n.Left, f)
+		Inspect(n.Index, f)
+	case *MemberExpr:
+		Inspect(n.Object, f)
+		Inspect(n.Property, f)
+	case *MatchExpr:
+		Inspect(n.Subject, f)
+		for _, arm := range n.Arms {
+			Inspect(arm, f)
+		}
+	case *MatchArm:
+		Inspect(n.Pattern, f)
+		if n.Block != nil {
+			Inspect(n.Block, f)
+		} else {
+			Inspect(n.Expr, f)
+		}
+	case *LiteralPattern:
+		Inspect(n.Value, f)
+	case *RangePattern:
+		Inspect(n.Low, f)
+		Inspect(n.High, f)
+	case *OrPattern:
+		for _, alt := range n.Alternatives {
+			Inspect(alt, f)
+		}
+	case *ArrayPattern:
+		for _, el := range n.Elements {
+			Inspect(el, f)
+		}
+	case *MapPattern:
+		for i := range n.Keys {
+			Inspect(n.Keys[i], f)
+			Inspect(n.Values[i], f)
+		}
+	case *RestPattern:
+		Inspect(n.Ident, f)
+	case *DefaultPattern:
+		Inspect(n.Target, f)
+		Inspect(n.Default, f)
+	case *VariantPattern:
+		Inspect(n.Enum, f)
+		Inspect(n.Variant, f)
+		for _, arg := range n.Args {
+			Inspect(arg, f)
+		}
+	}
+}
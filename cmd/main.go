@@ -1,28 +1,104 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"github.com/slinky55/milo"
+	"github.com/slinky55/milo/ast"
 	"github.com/slinky55/milo/evaluator"
-	"github.com/slinky55/milo/lexer"
-	"github.com/slinky55/milo/parser"
+	"github.com/slinky55/milo/object"
 	"os"
+	"path/filepath"
 )
 
 func main() {
-	b, err := os.ReadFile("./test.milo") // just pass the file name
+	if len(os.Args) < 2 {
+		repl()
+		return
+	}
+
+	b, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	program, err := compile(string(b))
 	if err != nil {
-		fmt.Print(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
+	program.File = os.Args[1]
 
-	l := lexer.New(string(b))
-	p := parser.New(l)
+	e := newEvaluator(program)
 
-	program := p.Parse()
-	if len(p.Errors) > 0 {
-		println("parser had errors")
+	if _, err := e.Evaluate(); err != nil {
+		report(err)
+		os.Exit(1)
 	}
+}
 
+// repl reads programs from standard input one line at a time, echoing the
+// value of each expression.
+func repl() {
+	e := newEvaluator(nil)
+	e.Mode = evaluator.ReplMode
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print(">> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return
+		}
+
+		program, err := compile(scanner.Text())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+
+		e.Program = program
+		if _, err := e.Evaluate(); err != nil {
+			report(err)
+		}
+	}
+}
+
+// report writes an error stopping evaluation to standard error, with the
+// stack of the functions it was raised in.
+func report(err error) {
+	var raised *object.Error
+	if errors.As(err, &raised) {
+		fmt.Fprintln(os.Stderr, raised.Trace())
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
+
+// newEvaluator returns an evaluator compiling imported modules like the
+// main program and searching the directories listed in $MILOPATH for them.
+// Files may be accessed below $MILOROOT, or the working directory if it is
+// not set.
+func newEvaluator(program *ast.Program) *evaluator.Evaluator {
 	e := evaluator.New(program)
+	e.Compile = compile
+
+	e.FileRoot = "."
+	if root := os.Getenv("MILOROOT"); root != "" {
+		e.FileRoot = root
+	}
+
+	if path := os.Getenv("MILOPATH"); path != "" {
+		e.SearchPath = filepath.SplitList(path)
+	}
+
+	return e
+}
 
-	e.Evaluate()
+// compile parses, analyzes and optimizes source. Analyzer warnings are
+// reported to standard error.
+func compile(source string) (*ast.Program, error) {
+	return milo.Compile(source, os.Stderr)
 }
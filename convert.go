@@ -0,0 +1,210 @@
+package milo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/slinky55/milo/object"
+	"math"
+	"reflect"
+)
+
+var (
+	objectType = reflect.TypeOf((*object.Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// ToObject converts a Go value to a Milo object. See object.ToObject.
+func ToObject(value any) (object.Object, error) {
+	return object.ToObject(value)
+}
+
+// FromObject converts a Milo object to a Go value. See object.FromObject.
+func FromObject(obj object.Object) any {
+	return object.FromObject(obj)
+}
+
+// Wrap adapts the Go function fn to a Milo builtin. Parameters may be of
+// any boolean, string or numeric type, object.Object or any, and the last
+// one may be variadic. fn may return nothing, a value, an error, or a value
+// and an error. The builtin's arity and parameter types are taken from the
+// signature of fn.
+func Wrap(name string, fn any) (*object.Builtin, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%s: expected a function, found %T", name, fn)
+	}
+
+	t := v.Type()
+	b := &object.Builtin{
+		Name:  name,
+		Arity: object.Fixed(t.NumIn()),
+	}
+	if t.IsVariadic() {
+		b.Arity = object.Variadic(t.NumIn() - 1)
+	}
+
+	for n := 0; n < t.NumIn(); n++ {
+		in := t.In(n)
+		if t.IsVariadic() && n == t.NumIn()-1 {
+			in = in.Elem()
+		}
+
+		pt := paramType(in)
+		if pt == "" {
+			return nil, fmt.Errorf("%s: unsupported parameter type %s", name, in)
+		}
+		b.Params = append(b.Params, pt)
+	}
+
+	switch {
+	case t.NumOut() > 2,
+		t.NumOut() == 2 && t.Out(1) != errorType:
+		return nil, fmt.Errorf("%s: unsupported return types", name)
+	}
+
+	b.Fn = func(host object.Host, args ...object.Object) (object.Object, error) {
+		in, err := convertArgs(name, t, args)
+		if err != nil {
+			return nil, err
+		}
+		return convertResults(name, v.Call(in))
+	}
+
+	return b, nil
+}
+
+// convertArgs converts args to the parameter types of t. The builtin has
+// already checked their number and Milo types.
+func convertArgs(name string, t reflect.Type, args []object.Object) ([]reflect.Value, error) {
+	var in []reflect.Value
+	for n, arg := range args {
+		pt := t.In(min(n, t.NumIn()-1))
+		if t.IsVariadic() && n >= t.NumIn()-1 {
+			pt = pt.Elem()
+		}
+
+		value, err := fromObject(arg, pt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument %d %w", name, n+1, err)
+		}
+		in = append(in, value)
+	}
+
+	return in, nil
+}
+
+func convertResults(name string, out []reflect.Value) (object.Object, error) {
+	if len(out) > 0 && out[len(out)-1].Type() == errorType {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		out = out[:len(out)-1]
+	}
+
+	if len(out) == 0 {
+		return object.NULL, nil
+	}
+
+	obj, err := ToObject(out[0].Interface())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return obj, nil
+}
+
+var (
+	errNotInteger = errors.New("must be an integer")
+	errNegative   = errors.New("must not be negative")
+)
+
+// fromObject converts obj to a Go value of type t. The error completes a
+// sentence about the argument, as in "must be an integer".
+func fromObject(obj object.Object, t reflect.Type) (reflect.Value, error) {
+	if obj == nil {
+		obj = object.NULL
+	}
+
+	switch {
+	case t == objectType:
+		return reflect.ValueOf(&obj).Elem(), nil
+	case t.Kind() == reflect.Interface && t.NumMethod() == 0:
+		value := FromObject(obj)
+		if value == nil {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(value), nil
+	}
+
+	value := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, ok := obj.Value().(bool)
+		if !ok {
+			return value, mismatched(t)
+		}
+		value.SetBool(b)
+	case reflect.String:
+		if obj.Type() != object.STRING_OBJ {
+			return value, mismatched(t)
+		}
+		value.SetString(obj.Value().(string))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, ok := obj.Value().(float64)
+		if !ok || f != math.Trunc(f) {
+			return value, errNotInteger
+		}
+		if f < math.MinInt64 || f >= math.MaxInt64 || value.OverflowInt(int64(f)) {
+			return value, fmt.Errorf("is out of range for %s", t)
+		}
+		value.SetInt(int64(f))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		f, ok := obj.Value().(float64)
+		if !ok || f != math.Trunc(f) {
+			return value, errNotInteger
+		}
+		if f < 0 {
+			return value, errNegative
+		}
+		if f >= math.MaxUint64 || value.OverflowUint(uint64(f)) {
+			return value, fmt.Errorf("is out of range for %s", t)
+		}
+		value.SetUint(uint64(f))
+	case reflect.Float32, reflect.Float64:
+		f, ok := obj.Value().(float64)
+		if !ok {
+			return value, mismatched(t)
+		}
+		value.SetFloat(f)
+	default:
+		return value, mismatched(t)
+	}
+
+	return value, nil
+}
+
+// mismatched returns the error for an argument that is not of the Milo type
+// accepted for t.
+func mismatched(t reflect.Type) error {
+	return fmt.Errorf("must be %s", object.TypeName(paramType(t)))
+}
+
+// paramType is the Milo type accepted for a Go parameter type, or "" if
+// the type is not supported.
+func paramType(t reflect.Type) object.ObjectType {
+	switch t.Kind() {
+	case reflect.Bool:
+		return object.BOOLEAN_OBJ
+	case reflect.String:
+		return object.STRING_OBJ
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return object.NUMBER_OBJ
+	case reflect.Interface:
+		if t == objectType || t.NumMethod() == 0 {
+			return object.ANY
+		}
+	}
+	return ""
+}
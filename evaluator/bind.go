@@ -0,0 +1,90 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/slinky55/milo/ast"
+	"github.com/slinky55/milo/object"
+)
+
+// bind destructures value according to pattern, defining the variables it
+// names in env. A nil value is missing, which only a default can fill.
+func (e *Evaluator) bind(node ast.Pattern, value object.Object, env *object.Environment) error {
+	switch pattern := node.(type) {
+	case *ast.IdentExpr:
+		if value == nil {
+			return errorAt(pattern.Token, "missing value for %s", pattern.Value)
+		}
+		env.Set(pattern.Value, value)
+		return nil
+	case *ast.DefaultPattern:
+		if value == nil || value == object.NULL {
+			def, err := e.evalExpression(pattern.Default, env)
+			if err != nil {
+				return err
+			}
+			value = def
+		}
+		return e.bind(pattern.Target, value, env)
+	case *ast.ArrayPattern:
+		return e.bindArray(pattern, value, env)
+	case *ast.MapPattern:
+		return e.bindMap(pattern, value, env)
+	default:
+		return fmt.Errorf("invalid binding pattern: %s", pattern.ToString())
+	}
+}
+
+func (e *Evaluator) bindArray(pattern *ast.ArrayPattern, value object.Object, env *object.Environment) error {
+	arr, ok := value.(*object.Array)
+	if !ok {
+		return errorAt(pattern.Token, "cannot destructure %s as an array", typeOf(value))
+	}
+
+	for i, el := range pattern.Elements {
+		if rest, ok := el.(*ast.RestPattern); ok {
+			var elements []object.Object
+			if i < arr.Len() {
+				elements = append(elements, arr.Elements()[i:]...)
+			}
+			env.Set(rest.Ident.Value, object.NewArray(elements))
+			return nil
+		}
+
+		var item object.Object
+		if i < arr.Len() {
+			item = arr.Elements()[i]
+		} else if _, ok := el.(*ast.DefaultPattern); !ok {
+			return errorAt(pattern.Token, "cannot destructure %s: expected at least %d elements, found %d",
+				pattern.ToString(), i+1, arr.Len())
+		}
+
+		if err := e.bind(el, item, env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Evaluator) bindMap(pattern *ast.MapPattern, value object.Object, env *object.Environment) error {
+	m, ok := value.(*object.Map)
+	if !ok {
+		return errorAt(pattern.Token, "cannot destructure %s as a map", typeOf(value))
+	}
+
+	for i, key := range pattern.Keys {
+		item, ok := m.Get(object.NewString(key.Value))
+		if !ok {
+			if _, ok := pattern.Values[i].(*ast.DefaultPattern); !ok {
+				return errorAt(key.Token, "cannot destructure %s: missing key %s", pattern.ToString(), key.Value)
+			}
+			item = nil
+		}
+
+		if err := e.bind(pattern.Values[i], item, env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,14 +1,151 @@
 package evaluator
 
-import "github.com/slinky55/milo/object"
+import (
+	"fmt"
+	"github.com/slinky55/milo/object"
+	"io"
+	"sort"
+	"strings"
+)
 
-type Builtin func(...object.Object) object.Object
+var builtins = map[string]*object.Builtin{}
 
-var builtins = map[string]Builtin{
-	"print": Print,
+func init() {
+	register(&object.Builtin{
+		Name:   "print",
+		Arity:  object.Variadic(0),
+		Params: []object.ObjectType{object.ANY},
+		Doc:    "print(values...) writes values to standard output separated by spaces.",
+		Fn:     Print,
+	})
+	register(&object.Builtin{
+		Name:   "println",
+		Arity:  object.Variadic(0),
+		Params: []object.ObjectType{object.ANY},
+		Doc:    "println(values...) writes values to standard output separated by spaces and followed by a newline.",
+		Fn:     Println,
+	})
+	register(&object.Builtin{
+		Name:   "printf",
+		Arity:  object.Variadic(1),
+		Params: []object.ObjectType{object.STRING_OBJ, object.ANY},
+		Doc:    "printf(format, args...) writes args formatted according to format to standard output.",
+		Fn:     Printf,
+	})
+	register(&object.Builtin{
+		Name:   "sprintf",
+		Arity:  object.Variadic(1),
+		Params: []object.ObjectType{object.STRING_OBJ, object.ANY},
+		Doc:    "sprintf(format, args...) returns args formatted according to format.",
+		Fn:     Sprintf,
+	})
+	register(&object.Builtin{
+		Name:   "compose",
+		Arity:  object.Variadic(1),
+		Params: []object.ObjectType{object.FUNC_OBJ},
+		Doc:    "compose(fns...) returns a function passing its arguments to the first function and each result to the next, like x |> f |> g.",
+		Fn:     Compose,
+	})
+	register(&object.Builtin{
+		Name:   "error",
+		Arity:  object.Optional(1, 2),
+		Params: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ},
+		Doc:    "error(message, kind?) returns an error value with message, of kind Error unless given, for returning or throwing.",
+		Fn:     Error,
+	})
+	register(&object.Builtin{
+		Name:   "isError",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.ANY},
+		Doc:    "isError(value) reports whether value is an error, such as one returned by a failed file operation.",
+		Fn:     IsError,
+	})
 }
 
-func Print(args ...object.Object) object.Object {
-	println(args[0].Value())
-	return nil
+func register(b *object.Builtin) {
+	builtins[b.Name] = b
+}
+
+// Builtins returns the standard builtins sorted by name.
+func Builtins() []*object.Builtin {
+	var list []*object.Builtin
+	for _, b := range builtins {
+		list = append(list, b)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func Print(host object.Host, args ...object.Object) (object.Object, error) {
+	return write(host.Stdout(), join(args))
+}
+
+func Println(host object.Host, args ...object.Object) (object.Object, error) {
+	return write(host.Stdout(), join(args)+"\n")
+}
+
+func Printf(host object.Host, args ...object.Object) (object.Object, error) {
+	s, err := Format(args[0].Value().(string), args[1:]...)
+	if err != nil {
+		return nil, fmt.Errorf("printf: %w", err)
+	}
+	return write(host.Stdout(), s)
+}
+
+func Sprintf(host object.Host, args ...object.Object) (object.Object, error) {
+	s, err := Format(args[0].Value().(string), args[1:]...)
+	if err != nil {
+		return nil, fmt.Errorf("sprintf: %w", err)
+	}
+	return object.NewString(s), nil
+}
+
+func Compose(host object.Host, args ...object.Object) (object.Object, error) {
+	fns := append([]object.Object{}, args...)
+
+	return &object.Builtin{
+		Name:  "compose",
+		Arity: object.Variadic(0),
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			result, err := host.Apply(fns[0], args...)
+			for _, fn := range fns[1:] {
+				if err != nil {
+					break
+				}
+				result, err = host.Apply(fn, result)
+			}
+			return result, err
+		},
+	}, nil
+}
+
+func Error(host object.Host, args ...object.Object) (object.Object, error) {
+	kind := "Error"
+	if len(args) > 1 {
+		kind = str(args[1])
+	}
+	return object.NewError(kind, str(args[0])), nil
+}
+
+func IsError(host object.Host, args ...object.Object) (object.Object, error) {
+	_, ok := args[0].(*object.Error)
+	return object.NewBoolean(ok), nil
+}
+
+func join(args []object.Object) string {
+	var parts []string
+	for _, arg := range args {
+		parts = append(parts, toString(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+func write(w io.Writer, s string) (object.Object, error) {
+	if _, err := io.WriteString(w, s); err != nil {
+		return nil, err
+	}
+	return nil, nil
 }
@@ -0,0 +1,391 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/slinky55/milo/object"
+	"math"
+	"sort"
+)
+
+func init() {
+	register(&object.Builtin{
+		Name:   "map",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.ARRAY_OBJ, object.FUNC_OBJ},
+		Doc:    "map(arr, f) returns the results of calling f with each element of arr.",
+		Fn:     Map,
+	})
+	register(&object.Builtin{
+		Name:   "filter",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.ARRAY_OBJ, object.FUNC_OBJ},
+		Doc:    "filter(arr, pred) returns the elements of arr for which pred returns true.",
+		Fn:     Filter,
+	})
+	register(&object.Builtin{
+		Name:   "reduce",
+		Arity:  object.Optional(2, 3),
+		Params: []object.ObjectType{object.ARRAY_OBJ, object.FUNC_OBJ, object.ANY},
+		Doc:    "reduce(arr, f, initial?) combines the elements of arr by calling f(acc, element), starting from initial or the first element.",
+		Fn:     Reduce,
+	})
+	register(&object.Builtin{
+		Name:   "each",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.ARRAY_OBJ, object.FUNC_OBJ},
+		Doc:    "each(arr, f) calls f with each element of arr.",
+		Fn:     Each,
+	})
+	register(&object.Builtin{
+		Name:   "find",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.ARRAY_OBJ, object.FUNC_OBJ},
+		Doc:    "find(arr, pred) returns the first element of arr for which pred returns true, or null.",
+		Fn:     Find,
+	})
+	register(&object.Builtin{
+		Name:   "any",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.ARRAY_OBJ, object.FUNC_OBJ},
+		Doc:    "any(arr, pred) reports whether pred returns true for some element of arr.",
+		Fn:     Any,
+	})
+	register(&object.Builtin{
+		Name:   "all",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.ARRAY_OBJ, object.FUNC_OBJ},
+		Doc:    "all(arr, pred) reports whether pred returns true for every element of arr.",
+		Fn:     All,
+	})
+	register(&object.Builtin{
+		Name:   "sort",
+		Arity:  object.Optional(1, 2),
+		Params: []object.ObjectType{object.ARRAY_OBJ, object.FUNC_OBJ},
+		Doc:    "sort(arr, cmp?) returns arr sorted. cmp(a, b) returns a negative number if a goes first; by default numbers and strings are sorted in ascending order.",
+		Fn:     Sort,
+	})
+	register(&object.Builtin{
+		Name:   "reverse",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.ARRAY_OBJ},
+		Doc:    "reverse(arr) returns the elements of arr in reverse order.",
+		Fn:     Reverse,
+	})
+	register(&object.Builtin{
+		Name:   "zip",
+		Arity:  object.Variadic(1),
+		Params: []object.ObjectType{object.ARRAY_OBJ},
+		Doc:    "zip(arrs...) returns arrays of the elements at each position of arrs, up to the length of the shortest.",
+		Fn:     Zip,
+	})
+	register(&object.Builtin{
+		Name:   "range",
+		Arity:  object.Optional(1, 3),
+		Params: []object.ObjectType{object.NUMBER_OBJ, object.NUMBER_OBJ, object.NUMBER_OBJ},
+		Doc:    "range(end), range(start, end) and range(start, end, step) return the integers from start, 0 by default, up to but not including end.",
+		Fn:     Range,
+	})
+	register(&object.Builtin{
+		Name:   "enumerate",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.ARRAY_OBJ},
+		Doc:    "enumerate(arr) returns [index, element] pairs for the elements of arr.",
+		Fn:     Enumerate,
+	})
+	register(&object.Builtin{
+		Name:   "groupBy",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.ARRAY_OBJ, object.FUNC_OBJ},
+		Doc:    "groupBy(arr, f) returns a map from each key f returns to the elements of arr it returned it for.",
+		Fn:     GroupBy,
+	})
+	register(&object.Builtin{
+		Name:   "unique",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.ARRAY_OBJ},
+		Doc:    "unique(arr) returns the elements of arr without repetitions, in order of first appearance.",
+		Fn:     Unique,
+	})
+}
+
+func Map(host object.Host, args ...object.Object) (object.Object, error) {
+	var result []object.Object
+	for _, el := range elements(args[0]) {
+		value, err := host.Apply(args[1], el)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return object.NewArray(result), nil
+}
+
+func Filter(host object.Host, args ...object.Object) (object.Object, error) {
+	var result []object.Object
+	for _, el := range elements(args[0]) {
+		ok, err := test(host, "filter", args[1], el)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, el)
+		}
+	}
+	return object.NewArray(result), nil
+}
+
+func Reduce(host object.Host, args ...object.Object) (object.Object, error) {
+	els := elements(args[0])
+
+	var acc object.Object
+	if len(args) > 2 {
+		acc = args[2]
+	} else if len(els) == 0 {
+		return nil, fmt.Errorf("reduce: empty array and no initial value")
+	} else {
+		acc, els = els[0], els[1:]
+	}
+
+	for _, el := range els {
+		var err error
+		if acc, err = host.Apply(args[1], acc, el); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+func Each(host object.Host, args ...object.Object) (object.Object, error) {
+	for _, el := range elements(args[0]) {
+		if _, err := host.Apply(args[1], el); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func Find(host object.Host, args ...object.Object) (object.Object, error) {
+	for _, el := range elements(args[0]) {
+		ok, err := test(host, "find", args[1], el)
+		if err != nil || ok {
+			return el, err
+		}
+	}
+	return nil, nil
+}
+
+func Any(host object.Host, args ...object.Object) (object.Object, error) {
+	for _, el := range elements(args[0]) {
+		ok, err := test(host, "any", args[1], el)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return object.NewBoolean(true), nil
+		}
+	}
+	return object.NewBoolean(false), nil
+}
+
+func All(host object.Host, args ...object.Object) (object.Object, error) {
+	for _, el := range elements(args[0]) {
+		ok, err := test(host, "all", args[1], el)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return object.NewBoolean(false), nil
+		}
+	}
+	return object.NewBoolean(true), nil
+}
+
+func Sort(host object.Host, args ...object.Object) (object.Object, error) {
+	result := append([]object.Object{}, elements(args[0])...)
+
+	less := func(a, b object.Object) (bool, error) {
+		return compare(a, b)
+	}
+	if len(args) > 1 {
+		less = func(a, b object.Object) (bool, error) {
+			value, err := host.Apply(args[1], a, b)
+			if err != nil {
+				return false, err
+			}
+
+			n, ok := number(value)
+			if !ok {
+				return false, fmt.Errorf("sort: comparator must return a number, found %s", typeOf(value))
+			}
+			return n < 0, nil
+		}
+	}
+
+	// the first error stops the comparisons that would follow it
+	var err error
+	sort.SliceStable(result, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+
+		var ok bool
+		ok, err = less(result[i], result[j])
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewArray(result), nil
+}
+
+func Reverse(host object.Host, args ...object.Object) (object.Object, error) {
+	els := elements(args[0])
+
+	result := make([]object.Object, len(els))
+	for i, el := range els {
+		result[len(els)-1-i] = el
+	}
+	return object.NewArray(result), nil
+}
+
+func Zip(host object.Host, args ...object.Object) (object.Object, error) {
+	n := math.MaxInt
+	for _, arg := range args {
+		n = min(n, len(elements(arg)))
+	}
+
+	var result []object.Object
+	for i := 0; i < n; i++ {
+		var tuple []object.Object
+		for _, arg := range args {
+			tuple = append(tuple, elements(arg)[i])
+		}
+		result = append(result, object.NewArray(tuple))
+	}
+	return object.NewArray(result), nil
+}
+
+func Range(host object.Host, args ...object.Object) (object.Object, error) {
+	bounds := make([]int, len(args))
+	for i, arg := range args {
+		n, ok := integer(arg)
+		if !ok {
+			return nil, fmt.Errorf("range: argument %d must be an integer", i+1)
+		}
+		bounds[i] = n
+	}
+
+	start, end, step := 0, bounds[0], 1
+	if len(bounds) > 1 {
+		start, end = bounds[0], bounds[1]
+	}
+	if len(bounds) > 2 {
+		step = bounds[2]
+	}
+
+	if step == 0 {
+		return nil, fmt.Errorf("range: step must not be zero")
+	}
+
+	// the bounds are at most maxInteger, so none of this overflows
+	count := 0
+	if step > 0 && end > start {
+		count = (end - start + step - 1) / step
+	} else if step < 0 && end < start {
+		count = (start - end - step - 1) / -step
+	}
+	if count > maxLength {
+		return nil, fmt.Errorf("range: %d elements exceeds the limit of %d", count, maxLength)
+	}
+
+	result := make([]object.Object, count)
+	for i := range result {
+		result[i] = object.NewNumber(float64(start + i*step))
+	}
+	return object.NewArray(result), nil
+}
+
+func Enumerate(host object.Host, args ...object.Object) (object.Object, error) {
+	var result []object.Object
+	for i, el := range elements(args[0]) {
+		result = append(result, object.NewArray([]object.Object{object.NewNumber(float64(i)), el}))
+	}
+	return object.NewArray(result), nil
+}
+
+func GroupBy(host object.Host, args ...object.Object) (object.Object, error) {
+	groups := object.NewMap()
+	for _, el := range elements(args[0]) {
+		key, err := host.Apply(args[1], el)
+		if err != nil {
+			return nil, err
+		}
+
+		group, ok := groups.Get(key)
+		if !ok {
+			group = object.NewArray(nil)
+		}
+
+		group = object.NewArray(append(elements(group), el))
+		if err := groups.Set(key, group); err != nil {
+			return nil, fmt.Errorf("groupBy: %w", err)
+		}
+	}
+	return groups, nil
+}
+
+func Unique(host object.Host, args ...object.Object) (object.Object, error) {
+	var result []object.Object
+	seen := make(map[object.HashKey]bool)
+
+	for _, el := range elements(args[0]) {
+		if key, ok := object.HashKeyOf(el); ok {
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, el)
+			}
+			continue
+		}
+
+		// values that cannot be hashed are compared one by one
+		found := false
+		for _, r := range result {
+			found = found || equals(el, r)
+		}
+		if !found {
+			result = append(result, el)
+		}
+	}
+	return object.NewArray(result), nil
+}
+
+func elements(obj object.Object) []object.Object {
+	return obj.(*object.Array).Elements()
+}
+
+// test calls the predicate pred of the builtin name with value.
+func test(host object.Host, name string, pred, value object.Object) (bool, error) {
+	result, err := host.Apply(pred, value)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := result.(*object.Boolean)
+	if !ok {
+		return false, fmt.Errorf("%s: function must return a boolean, found %s", name, typeOf(result))
+	}
+	return b.Value().(bool), nil
+}
+
+// compare orders numbers and strings for sort.
+func compare(a, b object.Object) (bool, error) {
+	switch {
+	case a.Type() == object.NUMBER_OBJ && b.Type() == object.NUMBER_OBJ:
+		return num(a) < num(b), nil
+	case a.Type() == object.STRING_OBJ && b.Type() == object.STRING_OBJ:
+		return str(a) < str(b), nil
+	default:
+		return false, fmt.Errorf("sort: cannot compare %s and %s", typeOf(a), typeOf(b))
+	}
+}
@@ -0,0 +1,99 @@
+package evaluator
+
+import (
+	"github.com/slinky55/milo/ast"
+	"github.com/slinky55/milo/object"
+)
+
+// newEnum returns the enum declared by stmt.
+func newEnum(stmt *ast.EnumStatement) *object.Enum {
+	enum := object.NewEnum(stmt.Name.Value)
+	for _, variant := range stmt.Variants {
+		fields := make([]string, len(variant.Fields))
+		for i, field := range variant.Fields {
+			fields[i] = field.Value
+		}
+		enum.AddVariant(variant.Name.Value, fields, variant.Fields == nil)
+	}
+	return enum
+}
+
+// constructVariant creates a value of v from the field values in args.
+func constructVariant(v *object.Variant, args []object.Object) (object.Object, error) {
+	values, err := fieldValues(v.Name(), v.Fields(), args)
+	if err != nil {
+		return nil, err
+	}
+	return object.NewEnumValue(v, values), nil
+}
+
+// enumMember returns the variant of enum named by expr: the value of a unit
+// variant, or the constructor of any other.
+func enumMember(expr *ast.MemberExpr, enum *object.Enum) (object.Object, error) {
+	v, ok := enum.Variant(expr.Property.Value)
+	if !ok {
+		return nil, errorAt(expr.Property.Token, "%s has no variant %s", enum.Name(), expr.Property.Value)
+	}
+
+	if unit, ok := v.Unit(); ok {
+		return unit, nil
+	}
+	return v, nil
+}
+
+// matchVariant matches value against pattern, binding the names among its
+// arguments in env.
+func (e *Evaluator) matchVariant(pattern *ast.VariantPattern, value object.Object, env *object.Environment) (bool, error) {
+	decl, ok := e.resolve(pattern.Enum.Value, env)
+	if !ok {
+		return false, errorAt(pattern.Enum.Token, "unknown enum %s", pattern.Enum.Value)
+	}
+
+	enum, ok := decl.(*object.Enum)
+	if !ok {
+		return false, errorAt(pattern.Enum.Token, "%s is not an enum, found %s", pattern.Enum.Value, typeOf(decl))
+	}
+
+	v, ok := enum.Variant(pattern.Variant.Value)
+	if !ok {
+		return false, errorAt(pattern.Variant.Token, "%s has no variant %s", enum.Name(), pattern.Variant.Value)
+	}
+
+	if pattern.Args != nil && len(pattern.Args) != len(v.Fields()) {
+		return false, errorAt(pattern.Token, "pattern for %s expects %d fields, found %d", v.Name(), len(v.Fields()), len(pattern.Args))
+	}
+
+	ev, ok := value.(*object.EnumValue)
+	if !ok || ev.Variant() != v {
+		return false, nil
+	}
+
+	for i, arg := range pattern.Args {
+		ok, err := e.matchPattern(arg, ev.Values()[i], env)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// bindsNames reports whether matching pattern binds any names.
+func bindsNames(node ast.Pattern) bool {
+	switch pattern := node.(type) {
+	case *ast.IdentExpr:
+		return true
+	case *ast.OrPattern:
+		for _, alt := range pattern.Alternatives {
+			if bindsNames(alt) {
+				return true
+			}
+		}
+	case *ast.VariantPattern:
+		for _, arg := range pattern.Args {
+			if bindsNames(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
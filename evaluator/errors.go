@@ -0,0 +1,211 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/slinky55/milo/ast"
+	"github.com/slinky55/milo/object"
+)
+
+// maxTrace is the number of innermost functions listed in the stack of an
+// error. Deeper ones, as in a stack overflow, are summarized.
+const maxTrace = 32
+
+// frame is a function being evaluated and the line of the statement it is
+// running. Calls in tail position reuse the frame of their caller, and
+// elided counts the callers replaced that way.
+type frame struct {
+	name   string
+	line   int
+	elided int
+}
+
+// String renders fr as an entry of the stack of an error, noting the tail
+// calls that led to it.
+func (fr *frame) String() string {
+	entry := fmt.Sprintf("%s (line %d)", fr.name, fr.line)
+	switch fr.elided {
+	case 0:
+		return entry
+	case 1:
+		return entry + " (1 tail call elided)"
+	default:
+		return entry + fmt.Sprintf(" (%d tail calls elided)", fr.elided)
+	}
+}
+
+// pushFrame adds a frame for the function name and returns it along with
+// a function removing it again.
+func (e *Evaluator) pushFrame(name string) (*frame, func()) {
+	fr := &frame{name: name}
+	e.frames = append(e.frames, fr)
+	return fr, func() { e.frames = e.frames[:len(e.frames)-1] }
+}
+
+// evalThrow raises the value of stmt, which must be an error or a message.
+func (e *Evaluator) evalThrow(stmt *ast.ThrowStatement, env *object.Environment) error {
+	value, err := e.evalExpression(stmt.Expr, env)
+	if err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case *object.Error:
+		return e.raise(value)
+	case *object.String:
+		return e.raise(object.NewError("Error", value.Value().(string)))
+	default:
+		return errorAt(stmt.Token, "cannot throw %s", typeOf(value))
+	}
+}
+
+// evalTryExpr evaluates the body of expr, running the catch block with
+// the error if it fails, and then the finally block. A return from the
+// finally block replaces the outcome of the others.
+func (e *Evaluator) evalTryExpr(expr *ast.TryExpr, env *object.Environment) (object.Object, error) {
+	value, err := e.evalGuarded(expr.Body, env)
+
+	if err != nil && expr.Catch != nil && e.catchable(err) {
+		var caught *object.Error
+		errors.As(e.raise(err), &caught)
+
+		scope := object.NewEnclosedEnvironment(env)
+		scope.Set(expr.Param.Value, caught)
+		value, err = e.evalGuarded(expr.Catch, scope)
+	}
+
+	if expr.Finally != nil {
+		result, ferr := e.evalGuarded(expr.Finally, env)
+		if ferr != nil {
+			return nil, ferr
+		}
+
+		if _, ok := result.(*object.ReturnValue); ok {
+			return result, nil
+		}
+	}
+
+	return value, err
+}
+
+// evalGuarded evaluates block like evalBlock, but performs a call in tail
+// position of a return in it, so that errors from the call are raised
+// within the block.
+func (e *Evaluator) evalGuarded(block *ast.StatementBlock, env *object.Environment) (object.Object, error) {
+	value, err := e.evalBlock(block, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if rv, ok := value.(*object.ReturnValue); ok {
+		if _, ok := rv.Unwrap().(*tailCall); ok {
+			result, err := e.resolveTailCall(rv.Unwrap())
+			if err != nil {
+				return nil, err
+			}
+			return object.NewReturnValue(result), nil
+		}
+	}
+
+	return value, nil
+}
+
+// catchable reports whether err is an error a program can catch, rather
+// than evaluation stopping because the context is done or a ? returning
+// from a function.
+func (e *Evaluator) catchable(err error) bool {
+	if _, ok := err.(*earlyReturn); ok {
+		return false
+	}
+	return e.ctx.Err() == nil
+}
+
+// raise makes err an *object.Error recording the line and stack it was
+// raised at, unless it already carries one. It must be called before the
+// frame the error happened in is removed. Errors caused by the context
+// being done are returned unchanged, as is a ? returning from a function.
+func (e *Evaluator) raise(err error) error {
+	if !e.catchable(err) {
+		return err
+	}
+
+	line := 0
+	if len(e.frames) > 0 {
+		line = e.frames[len(e.frames)-1].line
+	}
+
+	var raised *object.Error
+	if le, ok := err.(*locatedError); ok {
+		raised = object.NewRuntimeError(le.msg, err)
+		if le.line > 0 {
+			line = le.line
+		}
+	} else if !errors.As(err, &raised) {
+		raised = object.NewRuntimeError(err.Error(), err)
+	} else if raised.Raised() {
+		return err
+	}
+
+	stack := []string{}
+	for i := len(e.frames) - 1; i >= 0; i-- {
+		if len(stack) == maxTrace {
+			stack = append(stack, fmt.Sprintf("... %d more", i+1))
+			break
+		}
+		stack = append(stack, e.frames[i].String())
+	}
+
+	return raised.At(line, stack)
+}
+
+// errorField returns the field name of err.
+func errorField(expr *ast.MemberExpr, err *object.Error) (object.Object, error) {
+	switch expr.Property.Value {
+	case "message":
+		return object.NewString(err.Message()), nil
+	case "kind":
+		return object.NewString(err.Kind()), nil
+	case "line":
+		return object.NewNumber(float64(err.Line())), nil
+	case "stack":
+		return stringArray(err.Stack()), nil
+	default:
+		return nil, errorAt(expr.Property.Token, "error has no field %s", expr.Property.Value)
+	}
+}
+
+// track records that the innermost frame is running stmt.
+func (e *Evaluator) track(stmt ast.Statement) {
+	if line := statementLine(stmt); line > 0 && len(e.frames) > 0 {
+		e.frames[len(e.frames)-1].line = line
+	}
+}
+
+// statementLine returns the line stmt starts on, or zero if it is not
+// known.
+func statementLine(node ast.Statement) int {
+	switch stmt := node.(type) {
+	case *ast.ExpressionStatement:
+		return stmt.Token.Line
+	case *ast.LetStatement:
+		return stmt.Token.Line
+	case *ast.ReturnStatement:
+		return stmt.Token.Line
+	case *ast.ThrowStatement:
+		return stmt.Token.Line
+	case *ast.StructStatement:
+		return stmt.Token.Line
+	case *ast.AssignStatement:
+		return stmt.Token.Line
+	case *ast.ImplStatement:
+		return stmt.Token.Line
+	case *ast.EnumStatement:
+		return stmt.Token.Line
+	case *ast.ImportStatement:
+		return stmt.Token.Line
+	case *ast.ExportStatement:
+		return stmt.Token.Line
+	default:
+		return 0
+	}
+}
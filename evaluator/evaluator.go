@@ -1,181 +1,1043 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
 	"github.com/slinky55/milo/ast"
 	"github.com/slinky55/milo/object"
+	"github.com/slinky55/milo/token"
+	"io"
+	"math"
+	"os"
 )
 
+// Mode controls whether the evaluator echoes the values of top-level
+// expression statements.
+type Mode int
+
+const (
+	// ScriptMode evaluates programs silently; only the builtins write output.
+	ScriptMode Mode = iota
+
+	// ReplMode writes the value of each top-level expression statement to
+	// standard output as it is evaluated.
+	ReplMode
+)
+
+// DefaultMaxDepth is the call depth limit used by evaluators created with New.
+const DefaultMaxDepth = 10000
+
 type Evaluator struct {
 	Program *ast.Program
 
-	variables map[string]object.Object
+	Mode Mode
+
+	// MaxDepth is the maximum number of nested function calls before
+	// evaluation fails with a stack overflow. Calls in tail position reuse
+	// the caller's frame and do not count towards it. Zero means no limit.
+	MaxDepth int
+
+	// SearchPath lists directories searched for imported modules that are
+	// not found relative to the importing file.
+	SearchPath []string
+
+	// Compile turns the source of an imported module into a program. It
+	// defaults to Parse.
+	Compile func(source string) (*ast.Program, error)
+
+	// FileRoot is the directory the fs module may access. Relative paths
+	// are resolved against it and paths leading outside of it are refused.
+	// An empty FileRoot denies all file access.
+	FileRoot string
+
+	env      *object.Environment
+	builtins map[string]*object.Builtin
+	ctx      context.Context
+	depth    int
+
+	// file is the source file being evaluated, modules caches imported
+	// modules by absolute path, or by name for standard modules, and
+	// loading lists the files being evaluated, innermost last, to detect
+	// import cycles.
+	file    string
+	modules map[string]*object.Module
+	loading []string
+
+	// frames lists the functions being evaluated, innermost last, for the
+	// stacks of raised errors.
+	frames []*frame
+
+	stdout io.Writer
+	stderr io.Writer
 }
 
 func New(program *ast.Program) *Evaluator {
 	return &Evaluator{
-		Program:   program,
-		variables: make(map[string]object.Object),
+		Program:  program,
+		MaxDepth: DefaultMaxDepth,
+		Compile:  Parse,
+		modules:  make(map[string]*object.Module),
+		env:      object.NewEnvironment(),
+		builtins: make(map[string]*object.Builtin),
+		ctx:      context.Background(),
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
 	}
 }
 
-func (e *Evaluator) Evaluate() {
-	for _, stmt := range e.Program.Statements {
-		switch t := stmt.(type) {
-		case *ast.ExpressionStatement:
-			value, err := e.evalExpression(t.Expr)
-			if err != nil {
-				println(err.Error())
-				continue
-			}
-			if value != nil {
-				println(value.ToString())
-			}
-		case *ast.LetStatement:
-			value, err := e.evalExpression(t.Expr)
+// SetOutput sets where programs write their standard output and standard
+// error, including values and errors reported by Evaluate.
+func (e *Evaluator) SetOutput(stdout, stderr io.Writer) {
+	e.stdout = stdout
+	e.stderr = stderr
+}
+
+func (e *Evaluator) Stdout() io.Writer { return e.stdout }
+func (e *Evaluator) Stderr() io.Writer { return e.stderr }
+
+// Apply calls fn with args as part of the running evaluation.
+func (e *Evaluator) Apply(fn object.Object, args ...object.Object) (object.Object, error) {
+	return e.applyFunction(fn, args)
+}
+
+// Evaluate runs the evaluator's program in its global environment and
+// returns the value of the last expression statement.
+func (e *Evaluator) Evaluate() (object.Object, error) {
+	return e.Run(context.Background(), e.Program)
+}
+
+// Run evaluates program in the evaluator's global environment and returns
+// the value of its last expression statement. Evaluation stops at the first
+// uncaught error, which is an *object.Error unless ctx is done. Globals
+// defined by program remain visible to later calls. In ReplMode the value
+// of every expression statement is written to standard output.
+func (e *Evaluator) Run(ctx context.Context, program *ast.Program) (object.Object, error) {
+	defer e.withContext(ctx)()
+	defer e.withFile(program.File)()
+
+	_, pop := e.pushFrame("<main>")
+	defer pop()
+
+	var result object.Object = object.NULL
+	for _, stmt := range program.Statements {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		value, err := e.evalStatement(stmt, e.env)
+		if early, ok := err.(*earlyReturn); ok {
+			return nil, e.raise(early.uncaught())
+		}
+		if err != nil {
+			return nil, e.raise(err)
+		}
+
+		if rv, ok := value.(*object.ReturnValue); ok {
+			value, err := e.resolveTailCall(rv.Unwrap())
 			if err != nil {
-				println(err.Error())
-				continue
+				return nil, e.raise(err)
 			}
+			return value, nil
+		}
+
+		if _, ok := stmt.(*ast.ExpressionStatement); ok {
+			result = value
 
-			if value != nil {
-				e.variables[t.Ident.Value] = value
+			if e.Mode == ReplMode && value != object.NULL {
+				fmt.Fprintln(e.stdout, value.ToString())
 			}
-		default:
-			println("unexpected statement: ", t.Literal())
 		}
 	}
+
+	return result, nil
+}
+
+// Call calls the function value fn with args.
+func (e *Evaluator) Call(ctx context.Context, fn object.Object, args ...object.Object) (object.Object, error) {
+	defer e.withContext(ctx)()
+	return e.applyFunction(fn, args)
+}
+
+// Get returns the value of the global variable or builtin name.
+func (e *Evaluator) Get(name string) (object.Object, bool) {
+	return e.resolve(name, e.env)
+}
+
+// Set defines or replaces the global variable name.
+func (e *Evaluator) Set(name string, value object.Object) {
+	e.env.Set(name, value)
+}
+
+// Define makes b available to programs run by this evaluator, in addition
+// to the standard builtins.
+func (e *Evaluator) Define(b *object.Builtin) {
+	e.builtins[b.Name] = b
 }
 
-func (e *Evaluator) evalExpression(node ast.Expression) (object.Object, error) {
+// withContext makes ctx the context of the running evaluation and returns
+// a function restoring the previous one.
+func (e *Evaluator) withContext(ctx context.Context) func() {
+	prev := e.ctx
+	e.ctx = ctx
+	return func() { e.ctx = prev }
+}
+
+// resolve looks name up in env and then among the builtins. Variables
+// shadow builtins of the same name.
+func (e *Evaluator) resolve(name string, env *object.Environment) (object.Object, bool) {
+	if value, ok := env.Get(name); ok {
+		return value, true
+	}
+	if b, ok := e.builtins[name]; ok {
+		return b, true
+	}
+	if b, ok := builtins[name]; ok {
+		return b, true
+	}
+	return nil, false
+}
+
+func (e *Evaluator) evalStatement(node ast.Statement, env *object.Environment) (object.Object, error) {
+	e.track(node)
+
+	switch stmt := node.(type) {
+	case *ast.ExpressionStatement:
+		return e.evalExpression(stmt.Expr, env)
+	case *ast.LetStatement:
+		value, err := e.evalExpression(stmt.Expr, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := value.(*object.ReturnValue); ok {
+			return value, nil
+		}
+
+		if err := e.bind(stmt.Pattern, value, env); err != nil {
+			return nil, err
+		}
+		return object.NULL, nil
+	case *ast.ExportStatement:
+		return e.evalStatement(stmt.Let, env)
+	case *ast.ImportStatement:
+		if err := e.evalImport(stmt, env); err != nil {
+			return nil, err
+		}
+		return object.NULL, nil
+	case *ast.ReturnStatement:
+		// a return always leaves the current function, so its
+		// expression is in tail position
+		value, err := e.evalTail(stmt.Expr, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if rv, ok := value.(*object.ReturnValue); ok {
+			return rv, nil
+		}
+		return object.NewReturnValue(value), nil
+	case *ast.ThrowStatement:
+		return nil, e.evalThrow(stmt, env)
+	case *ast.StructStatement:
+		env.Set(stmt.Name.Value, newStruct(stmt))
+		return object.NULL, nil
+	case *ast.EnumStatement:
+		env.Set(stmt.Name.Value, newEnum(stmt))
+		return object.NULL, nil
+	case *ast.ImplStatement:
+		if err := e.evalImpl(stmt, env); err != nil {
+			return nil, err
+		}
+		return object.NULL, nil
+	case *ast.AssignStatement:
+		if err := e.evalAssign(stmt, env); err != nil {
+			return nil, err
+		}
+		return object.NULL, nil
+	default:
+		return nil, fmt.Errorf("unexpected statement: %s", stmt.Literal())
+	}
+}
+
+func (e *Evaluator) evalExpression(node ast.Expression, env *object.Environment) (object.Object, error) {
 	switch expr := node.(type) {
 	case *ast.NumberExpr:
 		return object.NewNumber(expr.Value), nil
 	case *ast.BooleanExpr:
 		return object.NewBoolean(expr.Value), nil
 	case *ast.IdentExpr:
-		value, ok := e.variables[expr.Value]
+		value, ok := e.resolve(expr.Value, env)
 		if !ok {
 			return nil, fmt.Errorf("invalid reference: %s is nil", expr.Value)
 		}
 		return value, nil
 	case *ast.StringExpr:
 		return object.NewString(expr.Value), nil
+	case *ast.NullExpr:
+		return object.NULL, nil
+	case *ast.ArrayExpr:
+		return e.evalArrayExpr(expr, env)
+	case *ast.MapExpr:
+		return e.evalMapExpr(expr, env)
+	case *ast.IndexExpr, *ast.MemberExpr:
+		value, _, err := e.evalChain(expr, env)
+		return value, err
 	case *ast.FunctionExpr:
-		return object.NewFunction(expr.Body.Statements, expr.Parameters), nil
+		return object.NewFunction(expr.Name, expr.Body.Statements, expr.Parameters, env), nil
 	case *ast.PrefixExpression:
-		return e.evalPrefixExpression(expr)
+		return e.evalPrefixExpression(expr, env)
 	case *ast.BinaryExpression:
-		return e.evalBinaryExpression(expr)
+		return e.evalBinaryExpression(expr, env)
+	case *ast.IfExpr:
+		return e.evalIfExpr(expr, env)
+	case *ast.TernaryExpr:
+		branch, err := e.selectTernary(expr, env)
+		if err != nil {
+			return nil, err
+		}
+		return e.evalExpression(branch, env)
+	case *ast.MatchExpr:
+		return e.evalMatchExpr(expr, env, false)
+	case *ast.TryExpr:
+		return e.evalTryExpr(expr, env)
+	case *ast.PropagateExpr:
+		return e.evalPropagateExpr(expr, env)
+	case *ast.PipeExpr:
+		return e.evalPipeExpr(expr, env, false)
 	case *ast.CallExpr:
-		var args []object.Object
+		value, _, err := e.evalCallExpr(expr, env, false)
+		return value, err
+	default:
+		return nil, fmt.Errorf("invalid expression type: %T", expr)
+	}
+}
 
-		for _, arg := range expr.Arguments {
-			value, err := e.evalExpression(arg)
-			if err != nil {
-				println(err.Error())
-				break
-			}
-			args = append(args, value)
+// evalTail evaluates an expression in tail position. A call to a user
+// function is not performed; it is returned as a tailCall so that the
+// function currently running can reuse its frame for it.
+func (e *Evaluator) evalTail(node ast.Expression, env *object.Environment) (object.Object, error) {
+	switch expr := node.(type) {
+	case *ast.CallExpr:
+		value, _, err := e.evalCallExpr(expr, env, true)
+		return value, err
+	case *ast.IfExpr:
+		block, err := e.selectBranch(expr, env)
+		if err != nil {
+			return nil, err
 		}
-
-		if len(args) != len(expr.Arguments) {
-			break
+		if block == nil {
+			return object.NULL, nil
 		}
-
-		switch idt := expr.Function.(type) {
-		case *ast.IdentExpr:
-			ident := idt.Value
-			if fn, ok := builtins[ident]; ok {
-				return fn(args...), nil
-			}
+		return e.evalTailBlock(block.Statements, env)
+	case *ast.TernaryExpr:
+		branch, err := e.selectTernary(expr, env)
+		if err != nil {
+			return nil, err
 		}
-
-		return nil, fmt.Errorf("unknown function: %s", expr.Function.ToString())
+		return e.evalTail(branch, env)
+	case *ast.MatchExpr:
+		return e.evalMatchExpr(expr, env, true)
+	case *ast.PipeExpr:
+		return e.evalPipeExpr(expr, env, true)
 	default:
-		return nil, fmt.Errorf("invalid expression type: %T", expr)
+		return e.evalExpression(node, env)
 	}
-	return nil, nil
 }
 
-func (e *Evaluator) evalPrefixExpression(expr *ast.PrefixExpression) (object.Object, error) {
-	right, err := e.evalExpression(expr.Right)
+func (e *Evaluator) evalPrefixExpression(expr *ast.PrefixExpression, env *object.Environment) (object.Object, error) {
+	right, err := e.evalExpression(expr.Right, env)
 	if err != nil {
 		return nil, err
 	}
-	switch expr.Operator {
-	case "!":
-		if right.Type() != object.BOOLEAN_OBJ {
-			return nil, fmt.Errorf("invalid operand %s for prefix !", right.ToString())
-		}
-		return object.NewBoolean(!right.(*object.Boolean).Value().(bool)), nil
-	case "-":
-		if right.Type() != object.NUMBER_OBJ {
-			return nil, fmt.Errorf("invalid operand %s for prefix -", right.ToString())
-		}
-		return object.NewNumber(-right.(*object.Number).Value().(float64)), nil
-	case "++":
-		if right.Type() != object.NUMBER_OBJ {
-			return nil, fmt.Errorf("invalid operand %s for prefix ++", right.ToString())
-		}
-		num := right.(*object.Number)
-		num.Increment()
-		return num, nil
-	case "--":
-		if right.Type() != object.NUMBER_OBJ {
-			return nil, fmt.Errorf("invalid operand %s for prefix --", right.ToString())
-		}
-		num := right.(*object.Number)
-		num.Decrement()
-		return num, nil
+	return PrefixOp(expr.Operator, right)
+}
+
+func (e *Evaluator) evalBinaryExpression(expr *ast.BinaryExpression, env *object.Environment) (object.Object, error) {
+	left, err := e.evalExpression(expr.Left, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// ?? only evaluates its right side when the left one is null
+	if expr.Operator == "??" && left != object.NULL {
+		return left, nil
+	}
+
+	right, err := e.evalExpression(expr.Right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return BinaryOp(expr.Operator, left, right)
+}
+
+func (e *Evaluator) evalIfExpr(expr *ast.IfExpr, env *object.Environment) (object.Object, error) {
+	block, err := e.selectBranch(expr, env)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return object.NULL, nil
+	}
+	return e.evalBlock(block, env)
+}
+
+// selectBranch evaluates the condition of expr and returns the block to
+// run, or nil if there is none.
+func (e *Evaluator) selectBranch(expr *ast.IfExpr, env *object.Environment) (*ast.StatementBlock, error) {
+	cond, err := e.evalExpression(expr.Condition, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if cond.Type() != object.BOOLEAN_OBJ {
+		return nil, fmt.Errorf("invalid condition %s for if", expr.Condition.ToString())
+	}
+
+	if cond.Value().(bool) {
+		return expr.Consequence, nil
+	}
+	return expr.Alternative, nil
+}
+
+// selectTernary evaluates the condition of expr and returns the branch to
+// evaluate.
+func (e *Evaluator) selectTernary(expr *ast.TernaryExpr, env *object.Environment) (ast.Expression, error) {
+	cond, err := e.evalExpression(expr.Condition, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if cond.Type() != object.BOOLEAN_OBJ {
+		return nil, errorAt(expr.Token, "invalid condition %s for ?:", expr.Condition.ToString())
+	}
+
+	if cond.Value().(bool) {
+		return expr.Consequence, nil
+	}
+	return expr.Alternative, nil
+}
+
+// evalMatchExpr evaluates the body of the first arm whose pattern matches
+// the subject. The body is in tail position if the match is.
+func (e *Evaluator) evalMatchExpr(expr *ast.MatchExpr, env *object.Environment, tail bool) (object.Object, error) {
+	subject, err := e.evalExpression(expr.Subject, env)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range expr.Arms {
+		// names bound by the pattern are only visible in its arm
+		scope := env
+		if bindsNames(arm.Pattern) {
+			scope = object.NewEnclosedEnvironment(env)
+		}
+
+		ok, err := e.matchPattern(arm.Pattern, subject, scope)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		switch {
+		case arm.Block != nil && tail:
+			return e.evalTailBlock(arm.Block.Statements, scope)
+		case arm.Block != nil:
+			return e.evalBlock(arm.Block, scope)
+		case tail:
+			return e.evalTail(arm.Expr, scope)
+		default:
+			return e.evalExpression(arm.Expr, scope)
+		}
+	}
+
+	return nil, errorAt(expr.Token, "no match arm for %s", object.Repr(subject))
+}
+
+func (e *Evaluator) matchPattern(node ast.Pattern, value object.Object, env *object.Environment) (bool, error) {
+	switch pattern := node.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.LiteralPattern:
+		lit, err := e.evalExpression(pattern.Value, env)
+		if err != nil {
+			return false, err
+		}
+		return equals(value, lit), nil
+	case *ast.RangePattern:
+		low, err := e.evalExpression(pattern.Low, env)
+		if err != nil {
+			return false, err
+		}
+
+		high, err := e.evalExpression(pattern.High, env)
+		if err != nil {
+			return false, err
+		}
+
+		if low.Type() != object.NUMBER_OBJ || high.Type() != object.NUMBER_OBJ {
+			return false, errorAt(pattern.Token, "range bounds must be numbers")
+		}
+
+		n, ok := value.(*object.Number)
+		if !ok {
+			return false, nil
+		}
+
+		f := n.Value().(float64)
+		return f >= low.Value().(float64) && f <= high.Value().(float64), nil
+	case *ast.OrPattern:
+		for _, alt := range pattern.Alternatives {
+			ok, err := e.matchPattern(alt, value, env)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case *ast.IdentExpr:
+		env.Set(pattern.Value, value)
+		return true, nil
+	case *ast.VariantPattern:
+		return e.matchVariant(pattern, value, env)
 	default:
-		return nil, fmt.Errorf("unknown prefix op: %s", expr.Operator)
+		return false, fmt.Errorf("invalid pattern type: %T", pattern)
+	}
+}
+
+func (e *Evaluator) evalBlock(block *ast.StatementBlock, env *object.Environment) (object.Object, error) {
+	var result object.Object = object.NULL
+
+	for _, stmt := range block.Statements {
+		value, err := e.evalStatement(stmt, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := value.(*object.ReturnValue); ok {
+			return value, nil
+		}
+		result = value
+	}
+
+	return result, nil
+}
+
+// evalTailBlock evaluates stmts with the last expression statement in
+// tail position.
+func (e *Evaluator) evalTailBlock(stmts []ast.Statement, env *object.Environment) (object.Object, error) {
+	var result object.Object = object.NULL
+
+	for i, stmt := range stmts {
+		var err error
+
+		if es, ok := stmt.(*ast.ExpressionStatement); ok && i == len(stmts)-1 {
+			e.track(es)
+			result, err = e.evalTail(es.Expr, env)
+		} else {
+			result, err = e.evalStatement(stmt, env)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := result.(*object.ReturnValue); ok {
+			return result, nil
+		}
 	}
+
+	return result, nil
 }
 
-func (e *Evaluator) evalBinaryExpression(expr *ast.BinaryExpression) (object.Object, error) {
-	left, err := e.evalExpression(expr.Left)
+// evalCallExpr evaluates a call. Like evalChain, it reports whether the
+// callee was skipped by an optional access. Piped values are passed before
+// all other arguments.
+func (e *Evaluator) evalCallExpr(expr *ast.CallExpr, env *object.Environment, tail bool, piped ...object.Object) (object.Object, bool, error) {
+	if ident, ok := expr.Function.(*ast.IdentExpr); ok {
+		if _, found := e.resolve(ident.Value, env); !found {
+			return nil, false, fmt.Errorf("unknown function: %s", ident.Value)
+		}
+	}
+
+	var fn object.Object
+	var args []object.Object
+	var skip bool
+	var err error
+
+	if member, ok := expr.Function.(*ast.MemberExpr); ok {
+		fn, args, skip, err = e.evalMethod(member, env)
+	} else {
+		fn, skip, err = e.evalChain(expr.Function, env)
+	}
+	if err != nil || skip {
+		return object.NULL, skip, err
+	}
+
+	if !object.IsCallable(fn) {
+		return nil, false, errorAt(expr.Token, "cannot call %s", object.TypeName(fn.Type()))
+	}
+
+	args = append(append([]object.Object{}, piped...), args...)
+	for _, arg := range expr.Arguments {
+		value, err := e.evalExpression(arg, env)
+		if err != nil {
+			return nil, false, err
+		}
+		args = append(args, value)
+	}
+
+	if len(expr.Named) > 0 {
+		if args, err = e.evalNamedArgs(expr, fn, args, env); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if f, ok := fn.(*object.Function); ok && tail {
+		return &tailCall{fn: f, args: args}, false, nil
+	}
+
+	value, err := e.applyFunction(fn, args)
+	return value, false, err
+}
+
+// evalPipeExpr passes the value of the left side to the right side. A call
+// on the right receives it as its first argument; any other function is
+// called with it alone.
+func (e *Evaluator) evalPipeExpr(expr *ast.PipeExpr, env *object.Environment, tail bool) (object.Object, error) {
+	value, err := e.evalExpression(expr.Left, env)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := e.evalExpression(expr.Right)
+	if call, ok := expr.Right.(*ast.CallExpr); ok {
+		result, _, err := e.evalCallExpr(call, env, tail, value)
+		return result, err
+	}
+
+	fn, err := e.evalExpression(expr.Right, env)
 	if err != nil {
 		return nil, err
 	}
 
-	switch expr.Operator {
-	case "+":
-		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
-			return nil, fmt.Errorf("invalid operand(s) for \"+\"")
+	if !object.IsCallable(fn) {
+		return nil, errorAt(expr.Token, "cannot pipe into %s", object.TypeName(fn.Type()))
+	}
+
+	if f, ok := fn.(*object.Function); ok && tail {
+		return &tailCall{fn: f, args: []object.Object{value}}, nil
+	}
+	return e.applyFunction(fn, []object.Object{value})
+}
+
+// evalMethod evaluates the callee of a call through a member expression.
+// A map entry or instance field of that name is called as is, and a
+// method of an instance's struct is called with the instance as self.
+// Otherwise the function of that name in scope is called with the object
+// as its first argument, so that arr.map(f) means map(arr, f). It returns
+// the function and the arguments to pass before the call's own.
+func (e *Evaluator) evalMethod(expr *ast.MemberExpr, env *object.Environment) (object.Object, []object.Object, bool, error) {
+	obj, skip, err := e.evalChain(expr.Object, env)
+	if err != nil || skip {
+		return object.NULL, nil, skip, err
+	}
+	if expr.Optional && obj == object.NULL {
+		return object.NULL, nil, true, nil
+	}
+
+	name := expr.Property.Value
+
+	switch obj := obj.(type) {
+	case *object.Map:
+		if value, ok := obj.Get(object.NewString(name)); ok {
+			return value, nil, false, nil
 		}
-		return object.NewNumber(left.Value().(float64) + right.Value().(float64)), nil
-	case "-":
-		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
-			return nil, fmt.Errorf("invalid operand(s) for \"-\"")
+	case *object.Instance:
+		if value, ok := obj.Get(name); ok {
+			return value, nil, false, nil
 		}
-		return object.NewNumber(left.Value().(float64) - right.Value().(float64)), nil
-	case "*":
-		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
-			return nil, fmt.Errorf("invalid operand(s) for \"*\"")
+		if method, ok := obj.Struct().Method(name); ok {
+			return method, []object.Object{obj}, false, nil
 		}
-		return object.NewNumber(left.Value().(float64) * right.Value().(float64)), nil
-	case "/":
-		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
-			return nil, fmt.Errorf("invalid operand(s) for \"/\"")
+	case *object.Module, *object.Enum:
+		value, err := e.evalMember(expr, obj)
+		return value, nil, false, err
+	}
+
+	if fn, ok := e.resolve(name, env); ok && object.IsCallable(fn) {
+		return fn, []object.Object{obj}, false, nil
+	}
+
+	value, err := e.evalMember(expr, obj)
+	return value, nil, false, err
+}
+
+// evalNamedArgs places the named arguments of expr at the positions of
+// their parameters, or the fields of a struct, in args. Parameters given no
+// argument are left nil.
+func (e *Evaluator) evalNamedArgs(expr *ast.CallExpr, fn object.Object, args []object.Object, env *object.Environment) ([]object.Object, error) {
+	var name string
+	var index func(string) int
+	var what string
+
+	switch fn := fn.(type) {
+	case *object.Function:
+		name, what = fn.Signature(), "parameter"
+		index = func(param string) int { return paramIndex(fn, param) }
+	case *object.Struct:
+		name, what = fn.Name(), "field"
+		index = fn.FieldIndex
+	case *object.Variant:
+		name, what = fn.Name(), "field"
+		index = fn.FieldIndex
+	default:
+		return nil, errorAt(expr.Named[0].Token, "%s does not accept named arguments", toString(fn))
+	}
+
+	for _, arg := range expr.Named {
+		i := index(arg.Name.Value)
+		if i < 0 {
+			return nil, errorAt(arg.Name.Token, "%s has no %s %s", name, what, arg.Name.Value)
 		}
-		return object.NewNumber(left.Value().(float64) / right.Value().(float64)), nil
-	case ">":
-		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
-			return nil, fmt.Errorf("invalid operand(s) for \">\"")
+
+		if i < len(args) && args[i] != nil {
+			return nil, errorAt(arg.Name.Token, "%s: argument %s given more than once", name, arg.Name.Value)
 		}
-		return object.NewBoolean(left.Value().(float64) > right.Value().(float64)), nil
-	case "<":
-		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
-			return nil, fmt.Errorf("invalid operand(s) for \"<\"")
+
+		value, err := e.evalExpression(arg.Value, env)
+		if err != nil {
+			return nil, err
+		}
+
+		for len(args) <= i {
+			args = append(args, nil)
+		}
+		args[i] = value
+	}
+
+	return args, nil
+}
+
+// paramIndex returns the position of the parameter called name, or -1.
+// Destructured and rest parameters cannot be named.
+func paramIndex(f *object.Function, name string) int {
+	for i, param := range f.Params() {
+		if dp, ok := param.(*ast.DefaultPattern); ok {
+			param = dp.Target
+		}
+
+		if ident, ok := param.(*ast.IdentExpr); ok && ident.Value == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// evalChain evaluates node, which may be part of a chain of member, index
+// and call expressions. It reports whether an optional access (?.) in the
+// chain found null, in which case the rest of the chain is skipped and
+// evaluates to null.
+func (e *Evaluator) evalChain(node ast.Expression, env *object.Environment) (object.Object, bool, error) {
+	switch expr := node.(type) {
+	case *ast.MemberExpr:
+		obj, skip, err := e.evalChain(expr.Object, env)
+		if err != nil || skip {
+			return object.NULL, skip, err
+		}
+		if expr.Optional && obj == object.NULL {
+			return object.NULL, true, nil
+		}
+
+		value, err := e.evalMember(expr, obj)
+		return value, false, err
+	case *ast.IndexExpr:
+		left, skip, err := e.evalChain(expr.Left, env)
+		if err != nil || skip {
+			return object.NULL, skip, err
+		}
+		if expr.Optional && left == object.NULL {
+			return object.NULL, true, nil
+		}
+
+		index, err := e.evalExpression(expr.Index, env)
+		if err != nil {
+			return nil, false, err
 		}
-		return object.NewBoolean(left.Value().(float64) < right.Value().(float64)), nil
+
+		value, err := e.evalIndex(expr, left, index)
+		return value, false, err
+	case *ast.CallExpr:
+		return e.evalCallExpr(expr, env, false)
+	default:
+		value, err := e.evalExpression(node, env)
+		return value, false, err
+	}
+}
+
+func (e *Evaluator) evalMember(expr *ast.MemberExpr, obj object.Object) (object.Object, error) {
+	name := expr.Property.Value
+
+	switch obj := obj.(type) {
+	case *object.Map:
+		if value, ok := obj.Get(object.NewString(name)); ok {
+			return value, nil
+		}
+		return object.NULL, nil
+	case *object.Module:
+		if value, ok := obj.Get(name); ok {
+			return value, nil
+		}
+		return nil, errorAt(expr.Property.Token, "%s has no export %s", obj.ToString(), name)
+	case *object.Error:
+		return errorField(expr, obj)
+	case *object.Instance:
+		if value, ok := obj.Get(name); ok {
+			return value, nil
+		}
+		if method, ok := obj.Struct().Method(name); ok {
+			return bindMethod(obj, method), nil
+		}
+		return nil, errorAt(expr.Property.Token, "%s has no field %s", obj.Struct().Name(), name)
+	case *object.Enum:
+		return enumMember(expr, obj)
+	case *object.EnumValue:
+		if value, ok := obj.Get(name); ok {
+			return value, nil
+		}
+		return nil, errorAt(expr.Property.Token, "%s has no field %s", obj.Variant().Name(), name)
 	default:
-		return nil, fmt.Errorf("invalid operator for binary expression %s", expr.Operator)
+		return nil, errorAt(expr.Token, "cannot access member %s of %s", name, object.TypeName(obj.Type()))
+	}
+}
+
+func (e *Evaluator) evalIndex(expr *ast.IndexExpr, left, index object.Object) (object.Object, error) {
+	switch left := left.(type) {
+	case *object.Array:
+		i, ok := integer(index)
+		if !ok {
+			return nil, errorAt(expr.Token, "array index must be an integer, found %s", index.ToString())
+		}
+		return left.Get(i), nil
+	case *object.String:
+		i, ok := integer(index)
+		if !ok {
+			return nil, errorAt(expr.Token, "string index must be an integer, found %s", index.ToString())
+		}
+		runes := []rune(left.Value().(string))
+		if i < 0 || i >= len(runes) {
+			return object.NULL, nil
+		}
+		return object.NewString(string(runes[i])), nil
+	case *object.Map:
+		if _, ok := object.HashKeyOf(index); !ok {
+			return nil, errorAt(expr.Token, "%s cannot be used as a map key", object.TypeName(index.Type()))
+		}
+		if value, ok := left.Get(index); ok {
+			return value, nil
+		}
+		return object.NULL, nil
+	default:
+		return nil, errorAt(expr.Token, "cannot index %s", object.TypeName(left.Type()))
+	}
+}
+
+func (e *Evaluator) evalArrayExpr(expr *ast.ArrayExpr, env *object.Environment) (object.Object, error) {
+	var elements []object.Object
+	for _, el := range expr.Elements {
+		value, err := e.evalExpression(el, env)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+
+	return object.NewArray(elements), nil
+}
+
+func (e *Evaluator) evalMapExpr(expr *ast.MapExpr, env *object.Environment) (object.Object, error) {
+	m := object.NewMap()
+
+	for i, k := range expr.Keys {
+		key, err := e.evalExpression(k, env)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := e.evalExpression(expr.Values[i], env)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := m.Set(key, value); err != nil {
+			return nil, errorAt(expr.Token, "%s", err.Error())
+		}
+	}
+
+	return m, nil
+}
+
+func (e *Evaluator) applyFunction(fn object.Object, args []object.Object) (object.Object, error) {
+	e.depth++
+	defer func() { e.depth-- }()
+
+	if e.MaxDepth > 0 && e.depth > e.MaxDepth {
+		return nil, fmt.Errorf("stack overflow: maximum call depth of %d exceeded", e.MaxDepth)
+	}
+
+	// the frame is pushed once the arguments are bound, so that errors
+	// binding them are raised in the caller, and reused by tail calls
+	var fr *frame
+
+	for {
+		if err := e.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if b, ok := fn.(*object.Builtin); ok {
+			return b.Call(e, args...)
+		}
+
+		if s, ok := fn.(*object.Struct); ok {
+			return construct(s, args)
+		}
+
+		if v, ok := fn.(*object.Variant); ok {
+			return constructVariant(v, args)
+		}
+
+		f, ok := fn.(*object.Function)
+		if !ok {
+			return nil, fmt.Errorf("not a function: %s", toString(fn))
+		}
+
+		env, err := e.bindParams(f, args)
+		if err != nil {
+			return nil, err
+		}
+
+		if fr == nil {
+			var pop func()
+			fr, pop = e.pushFrame("")
+			defer pop()
+		}
+		fr.name, fr.line = f.Name(), 0
+		if fr.name == "" {
+			fr.name = "fn"
+		}
+
+		result, err := e.evalTailBlock(f.Body(), env)
+		if early, ok := err.(*earlyReturn); ok {
+			result, err = early.value, nil
+		}
+		if err != nil {
+			return nil, e.raise(err)
+		}
+
+		if rv, ok := result.(*object.ReturnValue); ok {
+			result = rv.Unwrap()
+		}
+
+		// reuse this frame for calls in tail position
+		if tc, ok := result.(*tailCall); ok {
+			fn, args = tc.fn, tc.args
+			fr.elided++
+			continue
+		}
+
+		return result, nil
+	}
+}
+
+// bindParams binds args to the parameters of f in a new environment. Nil
+// arguments were not passed and take their parameter's default value.
+func (e *Evaluator) bindParams(f *object.Function, args []object.Object) (*object.Environment, error) {
+	arity := f.Arity()
+	if !arity.Accepts(len(args)) {
+		return nil, fmt.Errorf("%s: wrong number of arguments: expected %s, found %d", f.Signature(), arity, len(args))
+	}
+
+	env := object.NewEnclosedEnvironment(f.Env())
+	for i, param := range f.Params() {
+		if rest, ok := param.(*ast.RestPattern); ok {
+			var extra []object.Object
+			if i < len(args) {
+				extra = append(extra, args[i:]...)
+			}
+			env.Set(rest.Ident.Value, object.NewArray(extra))
+			break
+		}
+
+		var arg object.Object
+		if i < len(args) {
+			arg = args[i]
+		}
+
+		if _, ok := param.(*ast.DefaultPattern); !ok && arg == nil {
+			return nil, fmt.Errorf("%s: missing argument %s", f.Signature(), param.ToString())
+		}
+
+		if err := e.bind(param, arg, env); err != nil {
+			return nil, err
+		}
+	}
+
+	return env, nil
+}
+
+// resolveTailCall performs value if it is a pending tail call.
+func (e *Evaluator) resolveTailCall(value object.Object) (object.Object, error) {
+	if tc, ok := value.(*tailCall); ok {
+		return e.applyFunction(tc.fn, tc.args)
+	}
+	return value, nil
+}
+
+// tailCall is a call in tail position that has not been performed yet.
+type tailCall struct {
+	fn   *object.Function
+	args []object.Object
+}
+
+func (tc *tailCall) ToString() string        { return "tail call" }
+func (tc *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+func (tc *tailCall) Value() any              { return tc.fn }
+
+// errorAt returns an error located at the position of t.
+func errorAt(t *token.Token, format string, args ...any) error {
+	return &locatedError{line: t.Line, column: t.Column, msg: fmt.Sprintf(format, args...)}
+}
+
+// locatedError is an error at a known position in the source.
+type locatedError struct {
+	line, column int
+	msg          string
+}
+
+func (le *locatedError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", le.line, le.column, le.msg)
+}
+
+// maxInteger bounds the whole numbers a number represents exactly, and
+// that can be used as integers.
+const maxInteger = 1 << 53
+
+// maxLength bounds the elements of an array or the bytes of a string a
+// builtin creates, so that a mistaken argument fails instead of exhausting
+// memory.
+const maxLength = 1 << 24
+
+// integer returns the value of obj if it is a whole number no larger in
+// magnitude than maxInteger.
+func integer(obj object.Object) (int, bool) {
+	n, ok := obj.(*object.Number)
+	if !ok {
+		return 0, false
+	}
+
+	f := n.Value().(float64)
+	if f != math.Trunc(f) || math.Abs(f) > maxInteger {
+		return 0, false
 	}
+	return int(f), true
 }
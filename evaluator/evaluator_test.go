@@ -0,0 +1,1512 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/slinky55/milo/lexer"
+	"github.com/slinky55/milo/object"
+	"github.com/slinky55/milo/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func eval(t *testing.T, e *Evaluator, input string) (object.Object, error) {
+	l := lexer.New(input)
+	p := parser.New(l)
+
+	program := p.Parse()
+	if len(p.Errors) > 0 {
+		t.Fatalf("parser had errors: %v", p.Errors)
+	}
+
+	return e.Run(context.Background(), program)
+}
+
+func TestFunctionCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn (x, y) { return x + y; }; add(2, 3)", "5"},
+		{"let add = fn (x, y) { x + y }; add(2, 3)", "5"},
+		{"let max = fn (x, y) { if (x > y) { x } else { y } }; max(4, 9)", "9"},
+		{"let adder = fn (x) { fn (y) { x + y } }; let addTwo = adder(2); addTwo(40)", "42"},
+		{"let early = fn (x) { if (x > 1) { return 1; } 2 }; early(5)", "1"},
+		{"fn (x) { x * 2 }(21)", "42"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value == nil || value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let count = fn (n) { if (n == 0) { return 0; } return count(n - 1); }; count(100000)", "0"},
+		{"let count = fn (n, acc) { if (n == 0) { acc } else { count(n - 1, acc + 1) } }; count(100000, 0)", "100000"},
+		{"let even = fn (n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn (n) { if (n == 0) { false } else { even(n - 1) } }; even(50001)", "false"},
+	}
+
+	for _, test := range tests {
+		e := New(nil)
+		e.MaxDepth = 100
+
+		value, err := eval(t, e, test.input)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			continue
+		}
+
+		if value == nil || value.ToString() != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, toString(value))
+		}
+	}
+}
+
+func TestStackOverflow(t *testing.T) {
+	input := "let sum = fn (n) { if (n == 0) { 0 } else { n + sum(n - 1) } }; sum(1000)"
+
+	e := New(nil)
+	e.MaxDepth = 500
+
+	_, err := eval(t, e, input)
+	if err == nil || !strings.Contains(err.Error(), "stack overflow") {
+		t.Fatalf("expected stack overflow error, found %v", err)
+	}
+
+	e.MaxDepth = 2000
+	value, err := eval(t, e, "sum(1000)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if value.ToString() != "500500" {
+		t.Errorf("expected 500500, found %s", value.ToString())
+	}
+}
+
+func TestBuiltinArguments(t *testing.T) {
+	e := New(nil)
+	e.Define(&object.Builtin{
+		Name:   "pad",
+		Arity:  object.Optional(1, 2),
+		Params: []object.ObjectType{object.STRING_OBJ, object.NUMBER_OBJ},
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			return args[0], nil
+		},
+	})
+	e.Define(&object.Builtin{
+		Name:   "total",
+		Arity:  object.Variadic(1),
+		Params: []object.ObjectType{object.NUMBER_OBJ},
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			return object.NewNumber(float64(len(args))), nil
+		},
+	})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sprintf()", "sprintf: wrong number of arguments: expected at least 1, found 0"},
+		{"sprintf(1)", "sprintf: argument 1 must be string"},
+		{"pad()", "pad: wrong number of arguments: expected 1 to 2, found 0"},
+		{"pad(1)", "pad: argument 1 must be string"},
+		{"pad(\"a\", \"b\")", "pad: argument 2 must be number"},
+		{"pad(\"a\", 1, 2)", "pad: wrong number of arguments: expected 1 to 2, found 3"},
+		{"total()", "total: wrong number of arguments: expected at least 1, found 0"},
+		{"total(1, 2, fn (x) { x })", "total: argument 3 must be number"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, e, test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+
+	for _, input := range []string{"pad(\"a\")", "pad(\"a\", 2)", "total(1, 2, 3)"} {
+		if _, err := eval(t, e, input); err != nil {
+			t.Errorf("%s: unexpected error: %s", input, err)
+		}
+	}
+}
+
+func TestBuiltinValues(t *testing.T) {
+	e := New(nil)
+	e.Define(&object.Builtin{
+		Name:   "double",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.NUMBER_OBJ},
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			return object.NewNumber(args[0].Value().(float64) * 2), nil
+		},
+	})
+	e.Define(&object.Builtin{
+		Name:   "call",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.FUNC_OBJ},
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			return args[0], nil
+		},
+	})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print", "builtin print"},
+		{"let d = double; d(4)", "8"},
+		{"let apply = fn (f, x) { f(x) }; apply(double, 21)", "42"},
+		{"let twice = fn (f, x) { return f(f(x)); }; twice(double, 3)", "12"},
+		{"double == double", "true"},
+		{"let d = double; d == double", "true"},
+		{"print == double", "false"},
+		{"double != fn (x) { x }", "true"},
+		{"call(double)", "builtin double"},
+		{"call(fn (x) { x })", "function"},
+		{"let double = fn (x) { x }; double(5)", "5"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, e, test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value == nil || value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print(1, \"two\", true)", "1 two true"},
+		{"print(3 / 2); print(2)", "1.52"},
+		{"println(1, 2); println()", "1 2\n\n"},
+		{"println(print)", "builtin print\n"},
+		{"printf(\"%d items at %.2f\\n\", 3, 9 / 2)", "3 items at 4.50\n"},
+		{"printf(\"%s|%5s|%-5s|\", \"a\", \"b\", \"c\")", "a|    b|c    |"},
+		{"printf(\"%v %t %q %T %%\", 10 / 4, false, \"x\", println)", "2.5 false \"x\" function %"},
+		{"println(sprintf(\"%03d\", 7))", "007\n"},
+	}
+
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+
+		e := New(nil)
+		e.SetOutput(&stdout, &stderr)
+
+		if _, err := eval(t, e, test.input); err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if stdout.String() != test.expected {
+			t.Errorf("%s: expected output %q, found %q", test.input, test.expected, stdout.String())
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"printf(\"%d\")", "printf: missing argument for %d"},
+		{"printf(\"%d\", 1, 2)", "printf: too many arguments: format uses 1, found 2"},
+		{"sprintf(\"%d\", 3 / 2)", "sprintf: %d expects an integer, found 1.5"},
+		{"sprintf(\"%f\", \"x\")", "sprintf: %f expects a number, found string"},
+		{"sprintf(\"%t\", 1)", "sprintf: %t expects a boolean, found number"},
+		{"sprintf(\"%x\", 1)", "sprintf: unknown verb %x"},
+		{"sprintf(\"50%\")", "sprintf: missing verb at end of format"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		mode     Mode
+		input    string
+		output   string
+		expected string
+	}{
+		{ScriptMode, "let x = 2; x * 3; \"done\"", "", "done"},
+		{ReplMode, "let x = 2; x * 3; \"done\"", "6\ndone\n", "done"},
+		{ScriptMode, "let f = fn (x) { println(x); x }; f(1);", "1\n", "1"},
+		{ReplMode, "let f = fn (x) { println(x); x }; f(1);", "1\n1\n", "1"},
+		{ReplMode, "let x = 1;", "", "null"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := parser.New(l)
+
+		var stdout, stderr bytes.Buffer
+
+		e := New(p.Parse())
+		e.Mode = test.mode
+		e.SetOutput(&stdout, &stderr)
+
+		value, err := e.Evaluate()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if stdout.String() != test.output {
+			t.Errorf("%s: expected output %q, found %q", test.input, test.output, stdout.String())
+		}
+
+		if value == nil && test.expected != "" || value != nil && value.ToString() != test.expected {
+			t.Errorf("%s: expected value %q, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestEvaluateError(t *testing.T) {
+	l := lexer.New("println(1); undefined; println(2);")
+	p := parser.New(l)
+
+	var stdout, stderr bytes.Buffer
+
+	e := New(p.Parse())
+	e.SetOutput(&stdout, &stderr)
+
+	_, err := e.Evaluate()
+	if err == nil || err.Error() != "invalid reference: undefined is nil" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if stdout.String() != "1\n" {
+		t.Errorf("expected evaluation to stop at the error, found output %q", stdout.String())
+	}
+}
+
+func TestCollectionLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, \"two\", [3]]", "[1, \"two\", [3]]"},
+		{"{name: \"milo\", 1: true}", "{\"name\": \"milo\", 1: true}"},
+		{"[1, 2, 3][1]", "2"},
+		{"[1, 2, 3][3]", "null"},
+		{"\"héllo\"[1]", "é"},
+		{"let m = {a: 1}; m[\"a\"]", "1"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestMembers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let m = {a: {b: 2}}; m.a.b", "2"},
+		{"let m = {a: 1}; m.missing", "null"},
+		{"let m = {a: 1}; m.missing?.b", "null"},
+		{"let m = {a: 1}; m.missing?.b.c.d", "null"},
+		{"let m = {a: 1}; m.missing?.[0]", "null"},
+		{"let m = {a: null}; m.a?.b(1)", "null"},
+		{"let m = {f: fn (x) { x * 2 }}; m.f(4)", "8"},
+		{"let m = {f: fn (x) { {v: x} }}; m.f(4).v", "4"},
+		{"let m = {a: 1}; m?.a", "1"},
+		{"let m = null; m?.a ?? \"default\"", "default"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestAccessErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5[0]", "1:2: cannot index number"},
+		{"[1][\"a\"]", "1:4: array index must be an integer, found a"},
+		{"{}[[1]]", "1:3: array cannot be used as a map key"},
+		{"{[1]: 2}", "1:1: array cannot be used as a map key"},
+		{"let m = 5; m.a", "1:13: cannot access member a of number"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestNull(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"null", "null"},
+		{"let x = null; x", "null"},
+		{"null == null", "true"},
+		{"null != 0", "true"},
+		{"if (false) { 1 }", "null"},
+		{"let f = fn (x) { }; f(1)", "null"},
+		{"let f = fn (x) { let y = x; }; f(1)", "null"},
+		{"println(1) == null", "true"},
+		{"null ?? 5", "5"},
+		{"0 ?? 5", "0"},
+		{"false ?? true", "false"},
+		{"let f = fn (x) { undefined }; 1 ?? f(1)", "1"},
+	}
+
+	for _, test := range tests {
+		e := New(nil)
+		e.SetOutput(&bytes.Buffer{}, &bytes.Buffer{})
+
+		value, err := eval(t, e, test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value != object.NULL && test.expected == "null" {
+			t.Errorf("%s: expected the null singleton, found %s", test.input, toString(value))
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestNullErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = null; f(1)", "1:16: cannot call null"},
+		{"let m = {a: null};\nm.a(1)", "2:4: cannot call null"},
+		{"let m = null; m.a", "1:16: cannot access member a of null"},
+		{"let m = null; m[0]", "1:16: cannot index null"},
+		{"let m = {}; m.a.b", "1:16: cannot access member b of null"},
+		{"5(1)", "1:2: cannot call number"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"true ? 1 : 2", "1"},
+		{"1 > 2 ? \"a\" : \"b\"", "b"},
+		{"let x = 5; x < 0 ? -1 : x == 0 ? 0 : 1", "1"},
+		{"match (2) { 1 => \"one\", 2 => \"two\", _ => \"many\" }", "two"},
+		{"match (\"y\") { \"x\" | \"y\" => 1, _ => 2 }", "1"},
+		{"match (7) { 0..5 => \"low\", 6..10 => \"high\" }", "high"},
+		{"match (-1) { -1 => \"neg\", _ => \"other\" }", "neg"},
+		{"match (\"z\") { 1..9 => 1, _ => 2 }", "2"},
+		{"match (true) { false => 0, true => { let y = 2; y * 3 } }", "6"},
+		{"let f = fn (n, acc) { match (n) { 0 => acc, _ => f(n - 1, acc + n) } }; f(20000, 0)", "200010000"},
+		{"let f = fn (n) { n == 0 ? \"done\" : f(n - 1) }; f(20000)", "done"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestConditionalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 ? 2 : 3", "1:3: invalid condition 1 for ?:"},
+		{"match (3) { 1 => 1, 2 => 2 }", "1:1: no match arm for 3"},
+		{"match (\"a\") { 1 => 1 }", "1:1: no match arm for \"a\""},
+		{"match (1) { \"a\"..2 => 1 }", "1:13: range bounds must be numbers"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = [1, 2]; a + b", "3"},
+		{"let [a, ...rest] = [1, 2, 3]; rest", "[2, 3]"},
+		{"let [a, b, ...rest] = [1, 2]; rest", "[]"},
+		{"let [first] = [1, 2, 3]; first", "1"},
+		{"let {name, age} = {name: \"milo\", age: 3}; sprintf(\"%s %d\", name, age)", "milo 3"},
+		{"let {name: n} = {name: \"milo\"}; n", "milo"},
+		{"let {a: {b: [c, d]}} = {a: {b: [1, 2]}}; c + d", "3"},
+		{"let [a, b = a * 10] = [2]; b", "20"},
+		{"let {x = 5} = {}; x", "5"},
+		{"let {x = 5} = {x: null}; x", "5"},
+		{"let {x = 5} = {x: 1}; x", "1"},
+		{"let f = fn ([a, b]) { a * b }; f([3, 4])", "12"},
+		{"let f = fn ({x, y}) { x - y }; f({y: 1, x: 5})", "4"},
+		{"let f = fn (x, y = x + 1) { x * y }; f(3)", "12"},
+		{"let f = fn (x, y = x + 1) { x * y }; f(3, 2)", "6"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a] = 5;", "1:5: cannot destructure number as an array"},
+		{"let {a} = [1];", "1:5: cannot destructure array as a map"},
+		{"let [a, b] = [1];", "1:5: cannot destructure [a, b]: expected at least 2 elements, found 1"},
+		{"let {a, b} = {a: 1};", "1:9: cannot destructure {a, b}: missing key b"},
+		{"let {a: [x]} = {a: null};", "1:9: cannot destructure null as an array"},
+		{"let f = fn ([a]) { a }; f(\"a\")", "1:13: cannot destructure string as an array"},
+		{"let f = fn (x, y = 1) { x }; f()", "f(x, y = 1): wrong number of arguments: expected 1 to 2, found 0"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fn() { 42 }; f()", "42"},
+		{"let f = fn(x, y = 10) { x + y }; f(1)", "11"},
+		{"let f = fn(x, y = 10) { x + y }; f(1, 2)", "3"},
+		{"let f = fn(first, ...rest) { rest }; f(1, 2, 3)", "[2, 3]"},
+		{"let f = fn(first, ...rest) { rest }; f(1)", "[]"},
+		{"let f = fn(x, y) { x - y }; f(y: 1, x: 5)", "4"},
+		{"let f = fn(x, y = 2, z = 3) { [x, y, z] }; f(1, z: 30)", "[1, 2, 30]"},
+		{"let f = fn(n, acc = 0) { if (n == 0) { return acc; } f(n - 1, acc: acc + n) }; f(20000)", "200010000"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestParameterErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(x, y) { x + y }; add(1)", "add(x, y): wrong number of arguments: expected 2, found 1"},
+		{"let f = fn(x, ...rest) { x }; f()", "f(x, ...rest): wrong number of arguments: expected at least 1, found 0"},
+		{"fn() { 1 }(2)", "fn(): wrong number of arguments: expected 0, found 1"},
+		{"let f = fn(x, y) { x }; f(y: 1)", "f(x, y): missing argument x"},
+		{"let f = fn(x) { x }; f(z: 1)", "1:24: f(x) has no parameter z"},
+		{"let f = fn(x) { x }; f(1, x: 2)", "1:27: f(x): argument x given more than once"},
+		{"println(x: 1)", "1:10: builtin println does not accept named arguments"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestArrowFunctionsAndMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let double = x => x * 2; double(21)", "42"},
+		{"((a, b) => a + b)(1, 2)", "3"},
+		{"(() => { let x = 1; x + 1 })()", "2"},
+		{"let add = x => y => x + y; add(1)(2)", "3"},
+		{"let apply = fn(f, x) { f(x) }; apply(x => x * x, 7)", "49"},
+		{"let double = x => x * 2; let n = 5; n.double()", "10"},
+		{"let inc = fn(x, by = 1) { x + by }; let n = 1; n.inc().inc(by: 10)", "12"},
+		{"let first = ([x]) => x; [3, 4].first()", "3"},
+		{"\"%d!\".sprintf(3)", "3!"},
+		{"let m = {size: () => 7}; let size = x => 0; m.size()", "7"},
+		{"let size = x => 0; let m = {}; m.size()", "0"},
+		{"let m = null; m?.double()", "null"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestPipelines(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let double = x => x * 2; 5 |> double", "10"},
+		{"let double = x => x * 2; let inc = x => x + 1; 5 |> double |> inc", "11"},
+		{"let sub = (a, b) => a - b; 10 |> sub(3)", "7"},
+		{"3 |> (x => x * x)", "9"},
+		{"\"%d-%d\" |> sprintf(1, 2)", "1-2"},
+		{"let m = {f: (x, y) => [x, y]}; 1 |> m.f(2)", "[1, 2]"},
+		{"let double = x => x * 2; let inc = x => x + 1; let f = compose(double, inc); f(5)", "11"},
+		{"let add = (a, b) => a + b; compose(add, x => x * 10)(1, 2)", "30"},
+		{"let inc = x => x + 1; let f = compose(inc, inc, inc); 1 |> f", "4"},
+		{"let loop = fn(n) { n == 0 ? 0 : n - 1 |> loop }; loop(20000)", "0"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 |> 2", "1:3: cannot pipe into number"},
+		{"compose()", "compose: wrong number of arguments: expected at least 1, found 0"},
+		{"compose(1)", "compose: argument 1 must be function"},
+		{"let f = compose(x => x, (a, b) => a); f(1)", "fn(a, b): wrong number of arguments: expected 2, found 1"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+// writeFiles creates files in a new temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func evalFile(t *testing.T, e *Evaluator, path, input string) (object.Object, error) {
+	l := lexer.New(input)
+	p := parser.New(l)
+
+	program := p.Parse()
+	if len(p.Errors) > 0 {
+		t.Fatalf("parser had errors: %v", p.Errors)
+	}
+	program.File = path
+
+	return e.Run(context.Background(), program)
+}
+
+func TestModules(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"utils.milo":      "let hidden = 2; export let double = x => x * hidden; export let [one, two] = [1, 2];",
+		"lib/count.milo":  "println(\"loading\"); export let n = 42;",
+		"lib/uses.milo":   "import \"count.milo\" as c; export let n = c.n + 1;",
+		"path/extra.milo": "export let name = \"extra\";",
+	})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"import \"utils.milo\" as u; u.double(4)", "8"},
+		{"import \"utils.milo\" as u; u.one + u.two", "3"},
+		{"import \"lib/uses.milo\" as u; u.n", "43"},
+		{"import \"lib/count.milo\" as a; import \"lib/count.milo\" as b; import \"lib/uses.milo\" as c; a.n + b.n", "84"},
+		{"import \"extra.milo\" as x; x.name", "extra"},
+		{"import \"utils.milo\" as u; 3 |> u.double", "6"},
+		{"let f = fn() { import \"utils.milo\" as u; u.double(1) }; f()", "2"},
+	}
+
+	for _, test := range tests {
+		var stdout bytes.Buffer
+		e := New(nil)
+		e.SetOutput(&stdout, &bytes.Buffer{})
+		e.SearchPath = []string{filepath.Join(dir, "path")}
+
+		value, err := evalFile(t, e, filepath.Join(dir, "main.milo"), test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+
+		if strings.Count(stdout.String(), "loading") > 1 {
+			t.Errorf("%s: module evaluated more than once", test.input)
+		}
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.milo":      "import \"b.milo\" as b; export let x = 1;",
+		"b.milo":      "import \"a.milo\" as a; export let y = 2;",
+		"main.milo":   "",
+		"self.milo":   "import \"main.milo\" as m;",
+		"broken.milo": "let = 1;",
+		"fails.milo":  "export let x = 1 / 0;",
+		"result.milo": "let x = Err(error(\"gone\"))?; export let y = 1;",
+		"utils.milo":  "let hidden = 1; export let shown = 2;",
+	})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"import \"missing.milo\" as m;", "1:1: module \"missing.milo\" not found"},
+		{"import \"a.milo\" as a;", "a.milo: b.milo: 1:1: import cycle: a.milo -> b.milo -> a.milo"},
+		{"import \"self.milo\" as s;", "self.milo: 1:1: import cycle: main.milo -> self.milo -> main.milo"},
+		{"import \"broken.milo\" as b;", "broken.milo: parser error: unexpected = in binding"},
+		{"import \"fails.milo\" as f;", "fails.milo: division by zero"},
+		{"import \"result.milo\" as r;", "result.milo: gone"},
+		{"import \"utils.milo\" as u; u.hidden", "1:29: module utils.milo has no export hidden"},
+	}
+
+	for _, test := range tests {
+		_, err := evalFile(t, New(nil), filepath.Join(dir, "main.milo"), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestMathModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"math.abs(-2.5)", "2.5"},
+		{"[math.floor(1.7), math.ceil(1.2), math.round(2.5), math.round(-2.5)]", "[1, 2, 3, -3]"},
+		{"math.sqrt(16)", "4"},
+		{"math.pow(2, 10)", "1024"},
+		{"[math.min(3, 1, 2), math.max(3, 1, 2)]", "[1, 3]"},
+		{"math.round(math.sin(math.PI / 2) * 1000)", "1000"},
+		{"math.cos(0) + math.tan(0) + math.asin(0) + math.acos(1) + math.atan(0)", "1"},
+		{"math.atan2(1, 1) == math.PI / 4", "true"},
+		{"[math.log(math.E), math.log10(1000), math.exp(0)]", "[1, 3, 1]"},
+		{"[math.gcd(12, 18), math.gcd(-4, 6), math.lcm(4, 6), math.lcm(0, 3)]", "[6, 2, 12, 0]"},
+		{"let r = math.random(); math.floor(r) == 0", "true"},
+		{"math.seed(7); let a = [math.random(100), math.random(1, 6)]; math.seed(7); let b = [math.random(100), math.random(1, 6)]; a[0] == b[0] ? a[1] == b[1] : false", "true"},
+		{"math.seed(1); let d = math.random(1, 6); d > 0 ? d < 7 : false", "true"},
+		{"math.random(5, 5)", "5"},
+		{"let r = math.random(-9007199254740992, 9007199254740992); r == math.floor(r)", "true"},
+		{"0.1 + 0.2 > 0.3", "true"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), "import \"math\" as math; "+test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestMathErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"math.sqrt(\"4\")", "math.sqrt: argument 1 must be number"},
+		{"math.gcd(1.5, 2)", "math.gcd: argument 1 must be an integer"},
+		{"math.random(0)", "math.random: argument 1 must be a positive integer"},
+		{"math.random(3, 1)", "math.random: empty range 3 to 1"},
+		{"math.random(-9000000000000000000, 9000000000000000000)", "math.random: argument 1 must be an integer"},
+		{"math.random(9000000000000000000)", "math.random: argument 1 must be a positive integer"},
+		{"math.min()", "math.min: wrong number of arguments: expected at least 1, found 0"},
+		{"math.tau", "1:29: module math has no export tau"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), "import \"math\" as math; "+test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestStringsModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"strings.split(\"a,b,,c\", \",\")", "[\"a\", \"b\", \"\", \"c\"]"},
+		{"strings.split(\"héj\", \"\")", "[\"h\", \"é\", \"j\"]"},
+		{"strings.join([\"a\", 1, true], \"-\")", "a-1-true"},
+		{"strings.join([], \", \")", ""},
+		{"strings.trim(\"  hi \\n\")", "hi"},
+		{"strings.upper(\"héllo\") + strings.lower(\"ÉCOLE\")", "HÉLLOécole"},
+		{"[strings.contains(\"milo\", \"il\"), strings.startsWith(\"milo\", \"mi\"), strings.endsWith(\"milo\", \"x\")]", "[true, true, false]"},
+		{"strings.replace(\"a.b.c\", \".\", \"::\")", "a::b::c"},
+		{"[strings.indexOf(\"héllo\", \"l\"), strings.indexOf(\"héllo\", \"z\"), strings.indexOf(\"abc\", \"a\")]", "[2, -1, 0]"},
+		{"strings.repeat(\"ab\", 3)", "ababab"},
+		{"strings.padLeft(\"7\", 3, \"0\")", "007"},
+		{"strings.padLeft(\"é\", 3) + \"|\"", "  é|"},
+		{"strings.padRight(\"ab\", 7, \"xyz\")", "abxyzxy"},
+		{"strings.padRight(\"long\", 2)", "long"},
+		{"strings.chars(\"añb\")", "[\"a\", \"ñ\", \"b\"]"},
+		{"strings.format(\"%s=%d\", \"x\", 3)", "x=3"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), "import \"strings\" as strings; "+test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestStringsErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"strings.upper(1)", "strings.upper: argument 1 must be string"},
+		{"strings.repeat(\"a\", -1)", "strings.repeat: argument 2 must be a non-negative integer"},
+		{"strings.padLeft(\"a\", 1.5)", "strings.padLeft: argument 2 must be an integer"},
+		{"strings.padRight(\"a\", 3, \"\")", "strings.padRight: argument 3 must not be empty"},
+		{"strings.repeat(\"a\", 9007199254740992)", "strings.repeat: result exceeds the limit of 16777216 bytes"},
+		{"strings.repeat(\"abcd\", 5000000)", "strings.repeat: result exceeds the limit of 16777216 bytes"},
+		{"strings.padLeft(\"a\", 9007199254740992)", "strings.padLeft: width exceeds the limit of 16777216 characters"},
+		{"strings.format(\"%d\")", "strings.format: missing argument for %d"},
+		{"strings.join(\"abc\", \"\")", "strings.join: argument 1 must be array"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), "import \"strings\" as strings; "+test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestCollections(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"map([1, 2, 3], x => x * 2)", "[2, 4, 6]"},
+		{"[1, 2, 3].map(x => x + 1).filter(x => x > 2)", "[3, 4]"},
+		{"[\"a\", \"b\"].map(sprintf)", "[\"a\", \"b\"]"},
+		{"reduce([1, 2, 3, 4], (acc, x) => acc + x)", "10"},
+		{"reduce([], (acc, x) => acc + x, 5)", "5"},
+		{"[\"a\", \"b\"].reduce((acc, x) => acc + x, \">\")", ">ab"},
+		{"each([1, 2], x => println(x)) == null", "true"},
+		{"find([1, 5, 10], x => x > 4)", "5"},
+		{"find([1, 2], x => x > 4)", "null"},
+		{"[any([1, 2], x => x > 1), any([], x => true), all([1, 2], x => x > 0), all([1, 2], x => x > 1)]", "[true, false, true, false]"},
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{"sort([\"b\", \"c\", \"a\"])", "[\"a\", \"b\", \"c\"]"},
+		{"sort([3, 1, 2], (a, b) => b - a)", "[3, 2, 1]"},
+		{"let a = [2, 1]; sort(a); a", "[2, 1]"},
+		{"reverse([1, 2, 3])", "[3, 2, 1]"},
+		{"zip([1, 2, 3], [\"a\", \"b\"])", "[[1, \"a\"], [2, \"b\"]]"},
+		{"[range(3), range(1, 4), range(10, 0, -3), range(0), range(0, 5, 2), range(3, 5, -1)]", "[[0, 1, 2], [1, 2, 3], [10, 7, 4, 1], [], [0, 2, 4], []]"},
+		{"enumerate([\"a\", \"b\"])", "[[0, \"a\"], [1, \"b\"]]"},
+		{"groupBy(range(5), x => x < 3 ? \"low\" : \"high\")", "{\"low\": [0, 1, 2], \"high\": [3, 4]}"},
+		{"groupBy([\"ab\", \"c\", \"de\"], s => s == \"c\" ? 1 : 2)", "{2: [\"ab\", \"de\"], 1: [\"c\"]}"},
+		{"unique([1, 2, 1, \"1\", 3, 2])", "[1, 2, \"1\", 3]"},
+		{"let sum = fn(...xs) { reduce(xs, (a, b) => a + b, 0) }; sum(1, 2, 3)", "6"},
+		{"range(5) |> filter(x => x > 2) |> map(x => x * x)", "[9, 16]"},
+	}
+
+	for _, test := range tests {
+		e := New(nil)
+		e.SetOutput(&bytes.Buffer{}, &bytes.Buffer{})
+
+		value, err := eval(t, e, test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestCollectionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"map(1, x => x)", "map: argument 1 must be array"},
+		{"map([1], 2)", "map: argument 2 must be function"},
+		{"filter([1], x => x)", "filter: function must return a boolean, found number"},
+		{"reduce([], (a, b) => a)", "reduce: empty array and no initial value"},
+		{"sort([1, \"a\"])", "sort: cannot compare string and number"},
+		{"sort([1, 2], (a, b) => true)", "sort: comparator must return a number, found boolean"},
+		{"range(0, 5, 0)", "range: step must not be zero"},
+		{"range(0.5)", "range: argument 1 must be an integer"},
+		{"range(9007199254740992, 9007199254740994)", "range: argument 2 must be an integer"},
+		{"range(1000000000000)", "range: 1000000000000 elements exceeds the limit of 16777216"},
+		{"groupBy([1], x => [x])", "groupBy: array cannot be used as a map key"},
+		{"map([1], (a, b) => a)", "fn(a, b): wrong number of arguments: expected 2, found 1"},
+		{"map([0], x => 1 / x)", "division by zero"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestFsModule(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"root/config.txt": "name=milo\r\nversion=1\n",
+		"root/data/a.txt": "a",
+		"root/data/b.txt": "b",
+		"root/empty.txt":  "",
+		"outside.milo":    "",
+	})
+	root := filepath.Join(dir, "root")
+	if err := os.Symlink(dir, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fs.readFile(\"data/a.txt\")", "a"},
+		{"fs.readLines(\"config.txt\")", "[\"name=milo\", \"version=1\"]"},
+		{"fs.readLines(\"empty.txt\")", "[]"},
+		{"fs.listDir(\"data\")", "[\"a.txt\", \"b.txt\"]"},
+		{"[fs.exists(\"data\"), fs.exists(\"data/a.txt\"), fs.exists(\"data/c.txt\")]", "[true, true, false]"},
+		{"fs.writeFile(\"out.txt\", \"one\"); fs.appendFile(\"out.txt\", \"two\"); fs.readFile(\"out.txt\")", "onetwo"},
+		{"fs.appendFile(\"log.txt\", \"x\"); fs.readFile(\"log.txt\")", "x"},
+		{"fs.mkdir(\"a/b\"); fs.writeFile(\"a/b/c.txt\", \"\"); fs.listDir(\"a/b\")", "[\"c.txt\"]"},
+		{"fs.writeFile(\"gone.txt\", \"\"); fs.remove(\"gone.txt\"); fs.exists(\"gone.txt\")", "false"},
+		{"fs.readFile(\"data/../data/b.txt\")", "b"},
+		{"fs.readFile(\"" + filepath.Join(root, "data", "a.txt") + "\")", "a"},
+		{"fs.readFile(\"missing.txt\")", "error: fs.readFile: missing.txt: no such file or directory"},
+		{"fs.remove(\"data\")", "error: fs.remove: data: directory not empty"},
+		{"fs.readFile(\"../outside.milo\")", "error: fs.readFile: ../outside.milo: path is outside the file root"},
+		{"fs.writeFile(\"escape/new.txt\", \"\")", "error: fs.writeFile: escape/new.txt: path is outside the file root"},
+		{"fs.exists(\"" + filepath.Join(dir, "outside.milo") + "\")", "error: fs.exists: " + filepath.Join(dir, "outside.milo") + ": path is outside the file root"},
+		{"[isError(fs.readFile(\"missing.txt\")), isError(fs.readFile(\"data/a.txt\"))]", "[true, false]"},
+	}
+
+	for _, test := range tests {
+		e := New(nil)
+		e.FileRoot = root
+
+		value, err := eval(t, e, "import \"fs\" as fs; "+test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if toString(value) != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); err == nil {
+		t.Errorf("writeFile escaped the file root through a symbolic link")
+	}
+}
+
+func TestFsModuleDisabled(t *testing.T) {
+	value, err := eval(t, New(nil), "import \"fs\" as fs; fs.readFile(\"milo.go\")")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := "error: fs.readFile: milo.go: file access is disabled"
+	if toString(value) != expected {
+		t.Errorf("expected %s, found %s", expected, toString(value))
+	}
+
+	_, err = eval(t, New(nil), "import \"fs\" as fs; fs.writeFile(\"x.txt\", 1)")
+	if err == nil || err.Error() != "fs.writeFile: argument 2 must be string" {
+		t.Errorf("expected argument error, found %v", err)
+	}
+}
+
+func TestJsonModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json.parse("{\"name\": \"milo\", \"tags\": [1, 2.5, true, null], \"nested\": {}}")`, `{"name": "milo", "tags": [1, 2.5, true, null], "nested": {}}`},
+		{`json.parse("\"a\\u00e9\\n\"")`, "aé\n"},
+		{`json.parse(" [] ")`, "[]"},
+		{`json.parse("{\"b\": 1, \"a\": 2}").a`, "2"},
+		{`json.stringify({"b": [1, 2], "a": null, 1: true})`, `{"b":[1,2],"a":null,"1":true}`},
+		{`json.stringify("<tag> \"quoted\"\n")`, `"<tag> \"quoted\"\n"`},
+		{`json.stringify(json.parse("1e21"))`, `1e+21`},
+		{`json.stringify({"a": [1, {}], "b": []}, 2)`, "{\n  \"a\": [\n    1,\n    {}\n  ],\n  \"b\": []\n}"},
+		{`json.stringify([1], 0)`, "[1]"},
+		{`isError(json.parse("{"))`, "true"},
+		{`json.parse("[1, 2")`, "error: json.parse: unexpected end of JSON input"},
+		{`json.parse("")`, "error: json.parse: unexpected end of JSON input"},
+		{`json.parse("[1] 2")`, "error: json.parse: unexpected data after the value"},
+		{`json.parse("{\"a\" 1}")`, "error: json.parse: invalid character '1' after object key"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), "import \"json\" as json; "+test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if toString(value) != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, toString(value))
+		}
+	}
+}
+
+func TestJsonRoundTrip(t *testing.T) {
+	tests := []string{
+		`null`,
+		`-12.5`,
+		`"multi\nline ✓"`,
+		`[1,[2,[3,[]]],{"x":false}]`,
+		`{"z":{"y":{"x":[null,true,"s"]}},"a":0.001}`,
+	}
+
+	for _, test := range tests {
+		value, err := ParseJSON(test)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test, err)
+			continue
+		}
+
+		s, err := StringifyJSON(value, "")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test, err)
+			continue
+		}
+
+		if s != test {
+			t.Errorf("expected %s, found %s", test, s)
+		}
+	}
+}
+
+func TestJsonModuleErrors(t *testing.T) {
+	cycle := object.NewMap()
+	inner := object.NewArray([]object.Object{object.NewNumber(1), cycle})
+	cycle.Set(object.NewString("self"), inner)
+
+	shared := object.NewArray(nil)
+	twice := object.NewArray([]object.Object{shared, shared})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"json.stringify(x => x)", "json.stringify: cannot encode function"},
+		{"json.stringify({\"f\": println})", "json.stringify: cannot encode function"},
+		{"json.stringify(json)", "json.stringify: cannot encode module"},
+		{"json.stringify(cycle)", "json.stringify: cannot encode a structure containing itself"},
+		{"json.stringify(1, -1)", "json.stringify: argument 2 must be a non-negative integer"},
+		{"json.parse(1)", "json.parse: argument 1 must be string"},
+	}
+
+	for _, test := range tests {
+		e := New(nil)
+		e.Set("cycle", cycle)
+
+		_, err := eval(t, e, "import \"json\" as json; "+test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+
+	// a value appearing twice is not a cycle
+	if s, err := StringifyJSON(twice, ""); err != nil || s != "[[],[]]" {
+		t.Errorf("expected [[],[]], found %s (%v)", s, err)
+	}
+}
+
+func TestExceptions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { 1 } catch (e) { 2 }", "1"},
+		{"try { throw \"bad\"; } catch (e) { e.message }", "bad"},
+		{"try { throw \"bad\"; } catch (e) { e.kind }", "Error"},
+		{"try { throw error(\"bad\", \"ValueError\"); } catch (e) { [e.kind, e.message] }", "[\"ValueError\", \"bad\"]"},
+		{"try { 1 / 0 } catch (e) { [e.kind, e.message] }", "[\"RuntimeError\", \"division by zero\"]"},
+		{"try { [1].x } catch (e) { [e.message, e.line] }", "[\"cannot access member x of array\", 1]"},
+		{"try { math.sqrt(\"x\") } catch (e) { e.message }", "math.sqrt: argument 1 must be number"},
+		{"try { map([1], x => x.y) } catch (e) { e.message }", "cannot access member y of number"},
+		{"let f = fn() {\n  throw \"bad\";\n};\nlet g = fn() {\n  let x = f();\n  x\n};\ntry { g() } catch (e) { [e.line, e.stack] }",
+			"[2, [\"f (line 2)\", \"g (line 5)\", \"<main> (line 8)\"]]"},
+		{"let f = fn() { throw \"inner\"; }; try { try { f() } catch (e) { throw e; } } catch (e) { e.stack }", "[\"f (line 1)\", \"<main> (line 1)\"]"},
+		{"try { try { throw \"a\"; } finally { println(\"cleanup\"); } } catch (e) { e.message }", "a"},
+		{"let r = try { 1 } finally { println(\"done\"); }; r", "1"},
+		{"let f = fn() { try { return 1; } finally { return 2; } }; f()", "2"},
+		{"let f = fn() { try { return 1; } catch (e) { 2 } }; f()", "1"},
+		{"let fail = fn() { throw \"tail\"; }; let f = fn() { try { return fail(); } catch (e) { e.message } }; f()", "tail"},
+		{"try { throw \"x\"; } catch (e) { let y = 1; }; e", "undefined"},
+		{"let e = error(\"returned\"); [isError(e), e.message, e.line, e.stack]", "[true, \"returned\", 0, []]"},
+		{"let countdown = fn(n) { n == 0 ? 0 : countdown(n - 1) }; try { countdown(100000) } catch (e) { e.message }", "0"},
+		{"let deep = fn(n) { 1 + deep(n + 1) }; try { deep(0) } catch (e) { [e.message, reduce(e.stack, (n, frame) => n + 1, 0)] }", "[\"stack overflow: maximum call depth of 10000 exceeded\", 33]"},
+	}
+
+	for _, test := range tests {
+		e := New(nil)
+		e.SetOutput(&bytes.Buffer{}, &bytes.Buffer{})
+
+		value, err := eval(t, e, "import \"math\" as math; "+test.input)
+		if test.expected == "undefined" {
+			if err == nil {
+				t.Errorf("%s: expected the catch variable to be out of scope", test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, value.ToString())
+		}
+	}
+}
+
+func TestUncaughtErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		trace    string
+	}{
+		{"throw \"bad\";", "bad", "Error: bad\n    at <main> (line 1)"},
+		{"let f = fn(x) {\n  x.y\n};\nf(1);", "2:4: cannot access member y of number", "RuntimeError: cannot access member y of number\n    at f (line 2)\n    at <main> (line 4)"},
+		{"throw 1;", "1:1: cannot throw number", "RuntimeError: cannot throw number\n    at <main> (line 1)"},
+		{"try { throw \"a\"; } catch (e) { throw error(\"b\", \"Wrapped\"); }", "b", "Wrapped: b\n    at <main> (line 1)"},
+		{"try { 1 } finally { throw \"in finally\"; }", "in finally", "Error: in finally\n    at <main> (line 1)"},
+		{"let g = fn() {\n  throw \"deep\";\n};\nlet f = fn() { g() };\nf();", "deep", "Error: deep\n    at g (line 2) (1 tail call elided)\n    at <main> (line 5)"},
+		{"let count = fn(n) {\n  if (n == 0) { throw \"done\"; }\n  count(n - 1)\n};\ncount(3);", "done", "Error: done\n    at count (line 2) (3 tail calls elided)\n    at <main> (line 5)"},
+		{"let open = fn() { Err(error(\"gone\", \"IOError\")) };\nlet f = open()?;\nprintln(\"after\");", "gone", "IOError: gone\n    at <main> (line 2)"},
+		{"Err(42)?;", "uncaught Err(42)", "RuntimeError: uncaught Err(42)\n    at <main> (line 1)"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+			continue
+		}
+
+		var raised *object.Error
+		if !errors.As(err, &raised) {
+			t.Errorf("%s: expected an error value, found %T", test.input, err)
+			continue
+		}
+
+		if raised.Trace() != test.trace {
+			t.Errorf("%s: expected trace %q, found %q", test.input, test.trace, raised.Trace())
+		}
+	}
+}
+
+func TestResults(t *testing.T) {
+	prelude := `
+		let parse = fn(s) { s == "" ? Err(error("empty input", "ParseError")) : Ok(s + "!") };
+		let twice = fn(a, b) { Ok([parse(a)?, parse(b)?]) };
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Ok(1)", "Ok(1)"},
+		{"Err(\"bad\")", "Err(\"bad\")"},
+		{"[isOk(Ok(1)), isOk(Err(1))]", "[true, false]"},
+		{"[unwrap(Ok(1)), unwrapOr(Err(1), 2), unwrapOr(Ok(3), 4)]", "[1, 2, 3]"},
+		{"Ok(1).unwrapOr(2)", "1"},
+		{"[Ok(1) == Ok(1), Ok(1) == Err(1), Err(\"a\") == Err(\"a\"), Ok([]) == Ok([])]", "[true, false, true, false]"},
+		{"twice(\"a\", \"b\")", "Ok([\"a!\", \"b!\"])"},
+		{"twice(\"a\", \"\")", "Err(error: empty input)"},
+		{"twice(\"\", \"b\") |> unwrapOr(\"fallback\")", "fallback"},
+		{"let calls = fn(s) { let n = parse(s)?; println(\"after\"); Ok(n) }; calls(\"\")", "Err(error: empty input)"},
+		{"let f = fn() { map([\"a\", \"\"], s => parse(s)?) }; f()", "[\"a!\", Err(error: empty input)]"},
+		{"let f = fn() { try { parse(\"\")?; } catch (e) { \"caught\" } }; f()", "Err(error: empty input)"},
+		{"let f = fn() { try { parse(\"\")? } finally { println(\"cleanup\"); } }; f()", "Err(error: empty input)"},
+		{"parse(\"x\")?", "x!"},
+		{"try { unwrap(parse(\"\")) } catch (e) { [e.kind, e.message] }", "[\"ParseError\", \"empty input\"]"},
+		{"try { unwrap(Err(42)) } catch (e) { e.message }", "unwrap: called on Err(42)"},
+		{"import \"json\" as json; let f = fn(s) { json.parse(s)?; Ok(s) }; f(\"{\")", "Err(error: json.parse: unexpected end of JSON input)"},
+		{"import \"fs\" as fs; let f = fn() { Ok(fs.readFile(\"a.txt\")?) }; f()", "Err(error: fs.readFile: a.txt: file access is disabled)"},
+		{"let f = fn() { let e = error(\"bad\", \"IOError\")?; Ok(e) }; f() |> unwrapOr(0)", "0"},
+		{"let f = fn() { g()? - 1 }; let g = fn() { Ok(3) }; f()", "2"},
+	}
+
+	for _, test := range tests {
+		e := New(nil)
+		e.SetOutput(&bytes.Buffer{}, &bytes.Buffer{})
+
+		value, err := eval(t, e, prelude+test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, value.ToString())
+		}
+	}
+}
+
+func TestResultErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1?", "1:2: ? expects a result, found number"},
+		{"let f = fn() { null? }; f()", "1:20: ? expects a result, found null"},
+		{"unwrap(1)", "unwrap: argument 1 must be result"},
+		{"isOk(Ok(1), 2)", "isOk: wrong number of arguments: expected 1, found 2"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestStructs(t *testing.T) {
+	prelude := `
+		struct Point { x, y }
+		struct Line { from, to }
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Point(1, 2)", "Point(x: 1, y: 2)"},
+		{"Point(y: 2, x: 1)", "Point(x: 1, y: 2)"},
+		{"Point(1, y: \"a\")", "Point(x: 1, y: \"a\")"},
+		{"Point", "struct Point"},
+		{"let p = Point(1, 2); p.x + p.y", "3"},
+		{"let p = Point(1, 2); p.x = 3; p", "Point(x: 3, y: 2)"},
+		{"let l = Line(Point(0, 0), Point(1, 1)); l.to.y = 5; l", "Line(from: Point(x: 0, y: 0), to: Point(x: 1, y: 5))"},
+		{"[Point(1, 2) == Point(1, 2), Point(1, 2) == Point(2, 1), Point(1, 2) != Point(1, 3)]", "[true, false, true]"},
+		{"struct Other { x, y } Point(1, 2) == Other(1, 2)", "false"},
+		{"Line(Point(0, 0), null) == Line(Point(0, 0), null)", "true"},
+		{"map([1, 2], x => Point(x, x))", "[Point(x: 1, y: 1), Point(x: 2, y: 2)]"},
+		{"struct Wrapper { value } 3 |> Wrapper", "Wrapper(value: 3)"},
+		{"struct Box { f } Box(x => x * 2).f(4)", "8"},
+		{"struct Unit {} Unit()", "Unit()"},
+		{"struct N { next } let n = N(null); n.next = n; n", "N(next: ...)"},
+		{"struct N { next } let n = N(null); n.next = [n, N(1)]; n", "N(next: [..., N(next: 1)])"},
+		{"struct N { next } let a = N(null); a.next = a; let b = N(null); b.next = b; [a == b, a == a]", "[true, true]"},
+		{"struct N { next } let a = N(null); a.next = N(a); let b = N(null); b.next = N(b); a == b", "true"},
+		{"struct N { next, v } let a = N(null, 1); a.next = a; let b = N(null, 2); b.next = b; a == b", "false"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), prelude+test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, value.ToString())
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Point(1)", "Point: missing field y"},
+		{"Point(1, 2, 3)", "Point: wrong number of arguments: expected 2, found 3"},
+		{"Point(1, z: 2)", "2:10: Point has no field z"},
+		{"Point(1, x: 2)", "2:10: Point: argument x given more than once"},
+		{"Point(1, 2).z", "2:13: Point has no field z"},
+		{"let p = Point(1, 2); p.z = 1;", "2:24: Point has no field z"},
+		{"let m = {}; m.x = 1;", "2:17: cannot assign to member x of map"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), "struct Point { x, y }\n"+test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	prelude := `
+		struct Point { x, y }
+		impl Point {
+			fn add(self, other) { Point(self.x + other.x, self.y + other.y) }
+			fn scale(self, k = 2) { self.x = self.x * k; self.y = self.y * k; self }
+		}
+		struct Counter { n }
+		impl Counter {
+			fn count(self, to) { self.n == to ? self.n : self.count2(to) }
+			fn count2(self, to) { self.n = self.n + 1; self.count(to) }
+		}
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Point(1, 2).add(Point(3, 4))", "Point(x: 4, y: 6)"},
+		{"let p = Point(1, 2); p.scale(); p", "Point(x: 2, y: 4)"},
+		{"Point(1, 2).scale().scale(k: 3)", "Point(x: 6, y: 12)"},
+		{"let p = Point(1, 1); map([Point(1, 2), Point(3, 4)], p.add)", "[Point(x: 2, y: 3), Point(x: 4, y: 5)]"},
+		{"Point(1, 2) |> x => x.add(x)", "Point(x: 2, y: 4)"},
+		{"impl Point { fn sum(self) { self.x + self.y } } Point(1, 2).sum()", "3"},
+		{"let k = 10; impl Point { fn shift(self) { Point(self.x + k, self.y) } } Point(1, 2).shift()", "Point(x: 11, y: 2)"},
+		{"impl Point { fn add(self, other) { \"replaced\" } } Point(1, 2).add(1)", "replaced"},
+		{"Counter(0).count(10000)", "10000"},
+		{"let len = fn(s) { 42 }; Point(1, 2).len()", "42"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), prelude+test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, value.ToString())
+		}
+	}
+}
+
+func TestMethodErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"impl Shape { fn f(self) { 1 } }", "2:6: unknown struct Shape"},
+		{"let n = 1; impl n { fn f(self) { 1 } }", "2:17: cannot impl n: not a struct, found number"},
+		{"impl Point { fn x(self) { 1 } }", "2:14: Point has a field x, which a method cannot replace"},
+		{"impl Point { fn f(self, a) { a } } Point(1, 2).f()", "Point.f(self, a): wrong number of arguments: expected 2, found 1"},
+		{"Point(1, 2).dist(1)", "2:13: Point has no field dist"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), "struct Point { x, y }\n"+test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	prelude := `
+		enum Shape { Circle(r), Rect(w, h), Empty }
+		enum Tree { Leaf, Node(left, value, right) }
+		let area = fn(s) {
+			match (s) {
+				Shape.Circle(r) => 3 * r * r,
+				Shape.Rect(w, h) => w * h,
+				Shape.Empty => 0
+			}
+		};
+		let sum = fn(t) {
+			match (t) {
+				Tree.Leaf => 0,
+				Tree.Node(l, v, r) => sum(l) + v + sum(r)
+			}
+		};
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Shape.Circle(2)", "Shape.Circle(2)"},
+		{"[Shape.Rect(h: 3, w: \"a\"), Shape.Empty]", "[Shape.Rect(\"a\", 3), Shape.Empty]"},
+		{"[Shape, Shape.Circle]", "[enum Shape, variant Shape.Circle]"},
+		{"map([Shape.Circle(2), Shape.Rect(2, 3), Shape.Empty], area)", "[12, 6, 0]"},
+		{"sum(Tree.Node(Tree.Node(Tree.Leaf, 1, Tree.Leaf), 2, Tree.Node(Tree.Leaf, 3, Tree.Leaf)))", "6"},
+		{"Shape.Rect(2, 3).h", "3"},
+		{"[Shape.Circle(1) == Shape.Circle(1), Shape.Circle(1) == Shape.Circle(2), Shape.Empty == Shape.Empty, Shape.Empty == Tree.Leaf]", "[true, false, true, false]"},
+		{"match (Shape.Rect(1, 5)) { Shape.Rect(1, h) | Shape.Rect(h, 1) => h, _ => 0 }", "5"},
+		{"match (Shape.Rect(2, 5)) { Shape.Rect(1, h) => h, Shape.Rect => \"rect\" }", "rect"},
+		{"match (Tree.Node(Tree.Leaf, 1, Tree.Leaf)) { Tree.Node(Tree.Leaf, v, _) => v }", "1"},
+		{"let r = 10; match (Shape.Circle(1)) { Shape.Circle(r) => r }; r", "10"},
+		{"match (5) { Shape.Empty => 0, 1..9 => 1 }", "1"},
+		{"[2, 3] |> map(Shape.Circle)", "[Shape.Circle(2), Shape.Circle(3)]"},
+		{"struct N { next } let n = N(null); let c = Shape.Circle(n); n.next = c; c", "Shape.Circle(N(next: Shape.Circle(...)))"},
+		{"struct N { next } let m = N(null); let n = N(null); m.next = Shape.Circle(m); n.next = Shape.Circle(n); m.next == n.next", "true"},
+	}
+
+	for _, test := range tests {
+		value, err := eval(t, New(nil), prelude+test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if value.ToString() != test.expected {
+			t.Errorf("%s: expected %s, found %s", test.input, test.expected, value.ToString())
+		}
+	}
+}
+
+func TestEnumErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Shape.Square", "2:7: Shape has no variant Square"},
+		{"Shape.Circle()", "Shape.Circle: missing field r"},
+		{"Shape.Circle(1, 2)", "Shape.Circle: wrong number of arguments: expected 1, found 2"},
+		{"Shape.Circle(1, z: 2)", "2:17: Shape.Circle has no field z"},
+		{"Shape.Empty()", "2:12: cannot call enum value"},
+		{"Shape.Circle(1).w", "2:17: Shape.Circle has no field w"},
+		{"match (Shape.Empty) { Shape.Circle(r) => r }", "2:1: no match arm for Shape.Empty"},
+		{"match (1) { Color.Red => 0 }", "2:13: unknown enum Color"},
+		{"match (1) { Shape.Square => 0 }", "2:19: Shape has no variant Square"},
+		{"match (1) { Shape.Rect(w) => 0 }", "2:13: pattern for Shape.Rect expects 2 fields, found 1"},
+		{"let n = 1; match (1) { n.x => 0 }", "2:24: n is not an enum, found number"},
+	}
+
+	for _, test := range tests {
+		_, err := eval(t, New(nil), "enum Shape { Circle(r), Rect(w, h), Empty }\n"+test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/slinky55/milo/object"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Format renders format with args the way printf and sprintf do. Verbs are
+// %v (any value), %s (any value as text), %q (quoted text), %d (integer),
+// %f, %e and %g (number), %t (boolean), %T (type name) and %% for a
+// literal percent sign. Flags, width and precision follow Go's fmt.
+func Format(format string, args ...object.Object) (string, error) {
+	var out strings.Builder
+	next := 0
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+
+		// collect flags, width and precision up to the verb
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0123456789.", format[i]) >= 0 {
+			i++
+		}
+
+		if i >= len(format) {
+			return "", fmt.Errorf("missing verb at end of format")
+		}
+
+		verb := format[i]
+		spec := format[start:i]
+
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+
+		if next >= len(args) {
+			return "", fmt.Errorf("missing argument for %%%c", verb)
+		}
+
+		s, err := formatArg(spec, verb, args[next])
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(s)
+		next++
+	}
+
+	if next < len(args) {
+		return "", fmt.Errorf("too many arguments: format uses %d, found %d", next, len(args))
+	}
+
+	return out.String(), nil
+}
+
+func formatArg(spec string, verb byte, arg object.Object) (string, error) {
+	switch verb {
+	case 'v', 's':
+		return fmt.Sprintf(spec+"s", toString(arg)), nil
+	case 'q':
+		return fmt.Sprintf(spec+"s", strconv.Quote(toString(arg))), nil
+	case 'T':
+		return fmt.Sprintf(spec+"s", typeOf(arg)), nil
+	case 'd':
+		n, ok := number(arg)
+		if !ok || n != math.Trunc(n) {
+			return "", fmt.Errorf("%%d expects an integer, found %s", toString(arg))
+		}
+		return fmt.Sprintf(spec+"d", int64(n)), nil
+	case 'f', 'e', 'g':
+		n, ok := number(arg)
+		if !ok {
+			return "", fmt.Errorf("%%%c expects a number, found %s", verb, typeOf(arg))
+		}
+		return fmt.Sprintf(spec+string(verb), n), nil
+	case 't':
+		b, ok := arg.(*object.Boolean)
+		if !ok {
+			return "", fmt.Errorf("%%t expects a boolean, found %s", typeOf(arg))
+		}
+		return fmt.Sprintf(spec+"t", b.Value().(bool)), nil
+	default:
+		return "", fmt.Errorf("unknown verb %%%c", verb)
+	}
+}
+
+func number(arg object.Object) (float64, bool) {
+	if n, ok := arg.(*object.Number); ok {
+		return n.Value().(float64), true
+	}
+	return 0, false
+}
+
+// toString renders arg as text, treating Go nil as null.
+func toString(arg object.Object) string {
+	if arg == nil {
+		return object.NULL.ToString()
+	}
+	return arg.ToString()
+}
+
+func typeOf(arg object.Object) string {
+	if arg == nil {
+		return object.TypeName(object.NULL_OBJ)
+	}
+	return object.TypeName(arg.Type())
+}
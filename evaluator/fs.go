@@ -0,0 +1,218 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/slinky55/milo/object"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	registerModule("fs", newFsModule)
+}
+
+// newFsModule builds the fs module. Its functions only reach files below
+// the evaluator's FileRoot, and report failures by returning an error value
+// instead of stopping the program.
+func newFsModule(e *Evaluator) *object.Module {
+	m := object.NewModule("fs")
+
+	exportBuiltin(m, &object.Builtin{
+		Name:   "fs.readFile",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.STRING_OBJ},
+		Doc:    "fs.readFile(path) returns the contents of the file at path.",
+		Fn: e.fileFunc("fs.readFile", func(path string, args []object.Object) (object.Object, error) {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			return object.NewString(string(b)), nil
+		}),
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "fs.readLines",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.STRING_OBJ},
+		Doc:    "fs.readLines(path) returns the lines of the file at path without their line endings.",
+		Fn: e.fileFunc("fs.readLines", func(path string, args []object.Object) (object.Object, error) {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+
+			lines := []object.Object{}
+			if len(b) > 0 {
+				for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+					lines = append(lines, object.NewString(strings.TrimSuffix(line, "\r")))
+				}
+			}
+			return object.NewArray(lines), nil
+		}),
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "fs.writeFile",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ},
+		Doc:    "fs.writeFile(path, contents) replaces the contents of the file at path, creating it if needed.",
+		Fn: e.fileFunc("fs.writeFile", func(path string, args []object.Object) (object.Object, error) {
+			return object.NULL, os.WriteFile(path, []byte(str(args[0])), 0644)
+		}),
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "fs.appendFile",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ},
+		Doc:    "fs.appendFile(path, contents) adds contents to the end of the file at path, creating it if needed.",
+		Fn: e.fileFunc("fs.appendFile", func(path string, args []object.Object) (object.Object, error) {
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = f.WriteString(str(args[0]))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			return object.NULL, err
+		}),
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "fs.exists",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.STRING_OBJ},
+		Doc:    "fs.exists(path) reports whether a file or directory exists at path.",
+		Fn: e.fileFunc("fs.exists", func(path string, args []object.Object) (object.Object, error) {
+			_, err := os.Stat(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				return object.NewBoolean(false), nil
+			}
+			return object.NewBoolean(err == nil), err
+		}),
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "fs.listDir",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.STRING_OBJ},
+		Doc:    "fs.listDir(path) returns the names of the entries of the directory at path in sorted order.",
+		Fn: e.fileFunc("fs.listDir", func(path string, args []object.Object) (object.Object, error) {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, err
+			}
+
+			names := []object.Object{}
+			for _, entry := range entries {
+				names = append(names, object.NewString(entry.Name()))
+			}
+			return object.NewArray(names), nil
+		}),
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "fs.mkdir",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.STRING_OBJ},
+		Doc:    "fs.mkdir(path) creates the directory at path along with any missing parents.",
+		Fn: e.fileFunc("fs.mkdir", func(path string, args []object.Object) (object.Object, error) {
+			return object.NULL, os.MkdirAll(path, 0755)
+		}),
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "fs.remove",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.STRING_OBJ},
+		Doc:    "fs.remove(path) removes the file or empty directory at path.",
+		Fn: e.fileFunc("fs.remove", func(path string, args []object.Object) (object.Object, error) {
+			return object.NULL, os.Remove(path)
+		}),
+	})
+
+	return m
+}
+
+// fileFunc returns a builtin function calling fn with the path its first
+// argument resolves to and the remaining arguments. Errors, including paths
+// outside the file root, are returned as error values naming the path as
+// the program gave it.
+func (e *Evaluator) fileFunc(name string, fn func(path string, args []object.Object) (object.Object, error)) object.BuiltinFunction {
+	return func(host object.Host, args ...object.Object) (object.Object, error) {
+		given := str(args[0])
+
+		path, err := e.resolvePath(given)
+		if err == nil {
+			var result object.Object
+			if result, err = fn(path, args[1:]); err == nil {
+				return result, nil
+			}
+		}
+
+		// the resolved path would reveal where the file root is
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return object.NewError("IOError", fmt.Sprintf("%s: %s: %s", name, given, err)), nil
+	}
+}
+
+// resolvePath returns the real path of name, resolved against FileRoot. It
+// fails if file access is disabled or the path, after following symbolic
+// links, is not inside the file root.
+func (e *Evaluator) resolvePath(name string) (string, error) {
+	if e.FileRoot == "" {
+		return "", errors.New("file access is disabled")
+	}
+
+	root, err := filepath.Abs(e.FileRoot)
+	if err != nil {
+		return "", err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", err
+	}
+
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+
+	path, err = realPath(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("path is outside the file root")
+	}
+	return path, nil
+}
+
+// realPath follows the symbolic links in path. Unlike filepath.EvalSymlinks
+// it allows the last elements of path not to exist yet, so files about to
+// be created can be checked.
+func realPath(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return real, nil
+	}
+
+	// a dangling link could point anywhere once its target is created
+	if _, lerr := os.Lstat(path); lerr == nil || !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+
+	real, err = realPath(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(real, filepath.Base(path)), nil
+}
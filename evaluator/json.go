@@ -0,0 +1,245 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/slinky55/milo/object"
+	"io"
+	"strings"
+)
+
+func init() {
+	registerModule("json", newJsonModule)
+}
+
+// newJsonModule builds the json module. JSON objects become maps keeping
+// the order of their keys, and numbers become Milo numbers.
+func newJsonModule(e *Evaluator) *object.Module {
+	m := object.NewModule("json")
+
+	exportBuiltin(m, &object.Builtin{
+		Name:   "json.parse",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.STRING_OBJ},
+		Doc:    "json.parse(s) returns the value encoded by the JSON text s, or an error if s is not valid JSON.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			value, err := ParseJSON(str(args[0]))
+			if err != nil {
+				return object.NewError("JSONError", "json.parse: "+err.Error()), nil
+			}
+			return value, nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "json.stringify",
+		Arity:  object.Optional(1, 2),
+		Params: []object.ObjectType{object.ANY, object.NUMBER_OBJ},
+		Doc:    "json.stringify(value, indent?) returns value encoded as JSON, indenting nested values by indent spaces if given.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			indent := 0
+			if len(args) > 1 {
+				n, ok := integer(args[1])
+				if !ok || n < 0 {
+					return nil, fmt.Errorf("json.stringify: argument 2 must be a non-negative integer")
+				}
+				indent = n
+			}
+
+			s, err := StringifyJSON(args[0], strings.Repeat(" ", indent))
+			if err != nil {
+				return nil, fmt.Errorf("json.stringify: %w", err)
+			}
+			return object.NewString(s), nil
+		},
+	})
+
+	return m
+}
+
+// ParseJSON decodes the JSON text s into Milo values.
+func ParseJSON(s string) (object.Object, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+
+	value, err := decodeJSON(dec)
+	if err != nil {
+		if err == io.EOF {
+			err = errors.New("unexpected end of JSON input")
+		}
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the value")
+	}
+	return value, nil
+}
+
+func decodeJSON(dec *json.Decoder) (object.Object, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			elements := []object.Object{}
+			for dec.More() {
+				el, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, el)
+			}
+
+			// the closing bracket
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return object.NewArray(elements), nil
+		}
+
+		m := object.NewMap()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := m.Set(object.NewString(key.(string)), value); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case bool:
+		return object.NewBoolean(tok), nil
+	case float64:
+		return object.NewNumber(tok), nil
+	case string:
+		return object.NewString(tok), nil
+	default:
+		return object.NULL, nil
+	}
+}
+
+// StringifyJSON encodes obj as JSON. If indent is not empty, each nested
+// value is put on its own line, indented by indent once per level. Map keys
+// that are not strings are written as text. Functions, other values JSON
+// cannot represent, and structures containing themselves are errors.
+func StringifyJSON(obj object.Object, indent string) (string, error) {
+	enc := &jsonEncoder{indent: indent, active: make(map[object.Object]bool)}
+	if err := enc.encode(obj, 0); err != nil {
+		return "", err
+	}
+	return enc.buf.String(), nil
+}
+
+type jsonEncoder struct {
+	buf    bytes.Buffer
+	indent string
+
+	// active holds the arrays and maps being encoded, to detect cycles.
+	active map[object.Object]bool
+}
+
+func (enc *jsonEncoder) encode(obj object.Object, depth int) error {
+	if obj == nil {
+		obj = object.NULL
+	}
+
+	switch obj := obj.(type) {
+	case *object.Null, *object.Boolean:
+		enc.buf.WriteString(obj.ToString())
+	case *object.Number:
+		b, err := json.Marshal(obj.Value())
+		if err != nil {
+			return fmt.Errorf("cannot encode %s", obj.ToString())
+		}
+		enc.buf.Write(b)
+	case *object.String:
+		enc.quote(obj.Value().(string))
+	case *object.Array:
+		if enc.active[obj] {
+			return errors.New("cannot encode a structure containing itself")
+		}
+		enc.active[obj] = true
+		defer delete(enc.active, obj)
+
+		enc.buf.WriteByte('[')
+		for i, el := range obj.Elements() {
+			if i > 0 {
+				enc.buf.WriteByte(',')
+			}
+			enc.newline(depth + 1)
+			if err := enc.encode(el, depth+1); err != nil {
+				return err
+			}
+		}
+		if obj.Len() > 0 {
+			enc.newline(depth)
+		}
+		enc.buf.WriteByte(']')
+	case *object.Map:
+		if enc.active[obj] {
+			return errors.New("cannot encode a structure containing itself")
+		}
+		enc.active[obj] = true
+		defer delete(enc.active, obj)
+
+		enc.buf.WriteByte('{')
+		for i, key := range obj.Keys() {
+			if i > 0 {
+				enc.buf.WriteByte(',')
+			}
+			enc.newline(depth + 1)
+
+			enc.quote(key.ToString())
+			enc.buf.WriteByte(':')
+			if enc.indent != "" {
+				enc.buf.WriteByte(' ')
+			}
+
+			value, _ := obj.Get(key)
+			if err := enc.encode(value, depth+1); err != nil {
+				return err
+			}
+		}
+		if obj.Len() > 0 {
+			enc.newline(depth)
+		}
+		enc.buf.WriteByte('}')
+	default:
+		return fmt.Errorf("cannot encode %s", typeOf(obj))
+	}
+
+	return nil
+}
+
+func (enc *jsonEncoder) newline(depth int) {
+	if enc.indent == "" {
+		return
+	}
+	enc.buf.WriteByte('\n')
+	enc.buf.WriteString(strings.Repeat(enc.indent, depth))
+}
+
+// quote writes s as a JSON string, leaving HTML characters unescaped.
+func (enc *jsonEncoder) quote(s string) {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	e.Encode(s)
+
+	enc.buf.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
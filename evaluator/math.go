@@ -0,0 +1,200 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/slinky55/milo/object"
+	"math"
+	"math/rand"
+	"time"
+)
+
+func init() {
+	registerModule("math", newMathModule)
+}
+
+// newMathModule builds the math module. Its random numbers come from a
+// source seeded with the current time unless a program calls seed.
+func newMathModule(e *Evaluator) *object.Module {
+	m := object.NewModule("math")
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	m.Export("PI", object.NewNumber(math.Pi))
+	m.Export("E", object.NewNumber(math.E))
+
+	unary := map[string]func(float64) float64{
+		"abs":   math.Abs,
+		"floor": math.Floor,
+		"ceil":  math.Ceil,
+		"round": math.Round,
+		"sqrt":  math.Sqrt,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+		"log":   math.Log,
+		"log10": math.Log10,
+		"exp":   math.Exp,
+	}
+	for name, fn := range unary {
+		exportBuiltin(m, &object.Builtin{
+			Name:   "math." + name,
+			Arity:  object.Fixed(1),
+			Params: []object.ObjectType{object.NUMBER_OBJ},
+			Doc:    fmt.Sprintf("math.%s(x) returns %s of x.", name, name),
+			Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+				return object.NewNumber(fn(num(args[0]))), nil
+			},
+		})
+	}
+
+	exportBuiltin(m, &object.Builtin{
+		Name:   "math.pow",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.NUMBER_OBJ, object.NUMBER_OBJ},
+		Doc:    "math.pow(x, y) returns x raised to the power y.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			return object.NewNumber(math.Pow(num(args[0]), num(args[1]))), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "math.atan2",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.NUMBER_OBJ, object.NUMBER_OBJ},
+		Doc:    "math.atan2(y, x) returns the angle of the point (x, y).",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			return object.NewNumber(math.Atan2(num(args[0]), num(args[1]))), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "math.min",
+		Arity:  object.Variadic(1),
+		Params: []object.ObjectType{object.NUMBER_OBJ},
+		Doc:    "math.min(values...) returns the smallest of its arguments.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			result := num(args[0])
+			for _, arg := range args[1:] {
+				result = math.Min(result, num(arg))
+			}
+			return object.NewNumber(result), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "math.max",
+		Arity:  object.Variadic(1),
+		Params: []object.ObjectType{object.NUMBER_OBJ},
+		Doc:    "math.max(values...) returns the largest of its arguments.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			result := num(args[0])
+			for _, arg := range args[1:] {
+				result = math.Max(result, num(arg))
+			}
+			return object.NewNumber(result), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "math.gcd",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.NUMBER_OBJ, object.NUMBER_OBJ},
+		Doc:    "math.gcd(a, b) returns the greatest common divisor of the integers a and b.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			a, b, err := integers("math.gcd", args)
+			if err != nil {
+				return nil, err
+			}
+			return object.NewNumber(float64(gcd(a, b))), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "math.lcm",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.NUMBER_OBJ, object.NUMBER_OBJ},
+		Doc:    "math.lcm(a, b) returns the least common multiple of the integers a and b.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			a, b, err := integers("math.lcm", args)
+			if err != nil {
+				return nil, err
+			}
+			if a == 0 || b == 0 {
+				return object.NewNumber(0), nil
+			}
+			return object.NewNumber(float64(absInt(a / gcd(a, b) * b))), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "math.seed",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.NUMBER_OBJ},
+		Doc:    "math.seed(n) seeds the random number source, making the numbers that follow reproducible.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			r.Seed(int64(num(args[0])))
+			return nil, nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "math.random",
+		Arity:  object.Optional(0, 2),
+		Params: []object.ObjectType{object.NUMBER_OBJ, object.NUMBER_OBJ},
+		Doc:    "math.random() returns a number in [0, 1). math.random(n) returns an integer in [0, n) and math.random(a, b) one in [a, b].",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			switch len(args) {
+			case 0:
+				return object.NewNumber(r.Float64()), nil
+			case 1:
+				n, ok := integer(args[0])
+				if !ok || n <= 0 {
+					return nil, fmt.Errorf("math.random: argument 1 must be a positive integer")
+				}
+				return object.NewNumber(float64(r.Int63n(int64(n)))), nil
+			default:
+				lo, hi, err := integers("math.random", args)
+				if err != nil {
+					return nil, err
+				}
+				if lo > hi {
+					return nil, fmt.Errorf("math.random: empty range %d to %d", lo, hi)
+				}
+				// both bounds are at most maxInteger, so the span fits
+				span := int64(hi) - int64(lo) + 1
+				return object.NewNumber(float64(int64(lo) + r.Int63n(span))), nil
+			}
+		},
+	})
+
+	return m
+}
+
+func num(obj object.Object) float64 {
+	return obj.Value().(float64)
+}
+
+// integers returns the first two arguments of the builtin name, which
+// must be whole numbers.
+func integers(name string, args []object.Object) (int, int, error) {
+	a, ok := integer(args[0])
+	if !ok {
+		return 0, 0, fmt.Errorf("%s: argument 1 must be an integer", name)
+	}
+
+	b, ok := integer(args[1])
+	if !ok {
+		return 0, 0, fmt.Errorf("%s: argument 2 must be an integer", name)
+	}
+
+	return a, b, nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return absInt(a)
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
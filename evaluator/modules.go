@@ -0,0 +1,204 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/slinky55/milo/ast"
+	"github.com/slinky55/milo/lexer"
+	"github.com/slinky55/milo/object"
+	"github.com/slinky55/milo/parser"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stdModules holds the constructors of the standard modules, which are
+// imported by bare name, as in import "math" as math;. Each evaluator
+// builds its own instance, which may read the evaluator's settings.
+var stdModules = map[string]func(e *Evaluator) *object.Module{}
+
+func registerModule(name string, build func(e *Evaluator) *object.Module) {
+	stdModules[name] = build
+}
+
+// exportBuiltin exports b from m under its name without the module prefix,
+// so math.abs is exported as abs.
+func exportBuiltin(m *object.Module, b *object.Builtin) {
+	m.Export(strings.TrimPrefix(b.Name, m.Name()+"."), b)
+}
+
+// Parse is the default Compile function of an Evaluator. It parses source
+// without optimizing it.
+func Parse(source string) (*ast.Program, error) {
+	p := parser.New(lexer.New(source))
+
+	program := p.Parse()
+	if len(p.Errors) > 0 {
+		return nil, errors.New(strings.Join(p.Errors, "\n"))
+	}
+	return program, nil
+}
+
+func (e *Evaluator) evalImport(stmt *ast.ImportStatement, env *object.Environment) error {
+	if build, ok := stdModules[stmt.Path.Value]; ok {
+		module, ok := e.modules[stmt.Path.Value]
+		if !ok {
+			module = build(e)
+			e.modules[stmt.Path.Value] = module
+		}
+
+		env.Set(stmt.Alias.Value, module)
+		return nil
+	}
+
+	path, ok := e.findModule(stmt.Path.Value)
+	if !ok {
+		return errorAt(stmt.Token, "module %q not found", stmt.Path.Value)
+	}
+
+	for i, loading := range e.loading {
+		if loading == path {
+			var cycle []string
+			for _, p := range append(e.loading[i:], path) {
+				cycle = append(cycle, filepath.Base(p))
+			}
+			return errorAt(stmt.Token, "import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	module, ok := e.modules[path]
+	if !ok {
+		var err error
+		if module, err = e.loadModule(stmt.Path.Value, path); err != nil {
+			return fmt.Errorf("%s: %w", stmt.Path.Value, err)
+		}
+		e.modules[path] = module
+	}
+
+	env.Set(stmt.Alias.Value, module)
+	return nil
+}
+
+// findModule resolves the import path name relative to the importing file
+// and then to each directory of the search path. It returns the absolute
+// path of the first file found.
+func (e *Evaluator) findModule(name string) (string, bool) {
+	dirs := append([]string{filepath.Dir(e.file)}, e.SearchPath...)
+	if filepath.IsAbs(name) {
+		dirs = []string{""}
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs, true
+		}
+	}
+
+	return "", false
+}
+
+// loadModule evaluates the module at path in its own global environment
+// and collects its exports.
+func (e *Evaluator) loadModule(name, path string) (*object.Module, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := e.Compile(string(source))
+	if err != nil {
+		return nil, err
+	}
+	program.File = path
+
+	defer e.withFile(path)()
+
+	_, pop := e.pushFrame("<module " + name + ">")
+	defer pop()
+
+	env := object.NewEnvironment()
+	for _, stmt := range program.Statements {
+		if err := e.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		value, err := e.evalStatement(stmt, env)
+		if early, ok := err.(*earlyReturn); ok {
+			return nil, e.raise(early.uncaught())
+		}
+		if err != nil {
+			return nil, e.raise(err)
+		}
+
+		if rv, ok := value.(*object.ReturnValue); ok {
+			if _, err := e.resolveTailCall(rv.Unwrap()); err != nil {
+				return nil, e.raise(err)
+			}
+			break
+		}
+	}
+
+	module := object.NewModule(name)
+	for _, stmt := range program.Statements {
+		export, ok := stmt.(*ast.ExportStatement)
+		if !ok {
+			continue
+		}
+
+		for _, name := range boundNames(export.Let.Pattern) {
+			if value, ok := env.Get(name); ok {
+				module.Export(name, value)
+			}
+		}
+	}
+
+	return module, nil
+}
+
+// withFile makes path the file being evaluated, which imports are resolved
+// against, and returns a function restoring the previous one.
+func (e *Evaluator) withFile(path string) func() {
+	file, loading := e.file, e.loading
+
+	e.file = path
+	if path != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			e.loading = append(e.loading[:len(e.loading):len(e.loading)], abs)
+		}
+	}
+
+	return func() {
+		e.file, e.loading = file, loading
+	}
+}
+
+// boundNames lists the variables a binding pattern defines.
+func boundNames(node ast.Pattern) []string {
+	switch pattern := node.(type) {
+	case *ast.IdentExpr:
+		return []string{pattern.Value}
+	case *ast.DefaultPattern:
+		return boundNames(pattern.Target)
+	case *ast.RestPattern:
+		return []string{pattern.Ident.Value}
+	case *ast.ArrayPattern:
+		var names []string
+		for _, el := range pattern.Elements {
+			names = append(names, boundNames(el)...)
+		}
+		return names
+	case *ast.MapPattern:
+		var names []string
+		for _, value := range pattern.Values {
+			names = append(names, boundNames(value)...)
+		}
+		return names
+	default:
+		return nil
+	}
+}
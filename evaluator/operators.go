@@ -0,0 +1,160 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/slinky55/milo/object"
+)
+
+// PrefixOp applies a prefix operator to an already evaluated operand.
+func PrefixOp(op string, right object.Object) (object.Object, error) {
+	switch op {
+	case "!":
+		if right.Type() != object.BOOLEAN_OBJ {
+			return nil, fmt.Errorf("invalid operand %s for prefix !", right.ToString())
+		}
+		return object.NewBoolean(!right.(*object.Boolean).Value().(bool)), nil
+	case "-":
+		if right.Type() != object.NUMBER_OBJ {
+			return nil, fmt.Errorf("invalid operand %s for prefix -", right.ToString())
+		}
+		return object.NewNumber(-right.(*object.Number).Value().(float64)), nil
+	case "++":
+		if right.Type() != object.NUMBER_OBJ {
+			return nil, fmt.Errorf("invalid operand %s for prefix ++", right.ToString())
+		}
+		num := right.(*object.Number)
+		num.Increment()
+		return num, nil
+	case "--":
+		if right.Type() != object.NUMBER_OBJ {
+			return nil, fmt.Errorf("invalid operand %s for prefix --", right.ToString())
+		}
+		num := right.(*object.Number)
+		num.Decrement()
+		return num, nil
+	default:
+		return nil, fmt.Errorf("unknown prefix op: %s", op)
+	}
+}
+
+// BinaryOp applies a binary operator to two already evaluated operands.
+func BinaryOp(op string, left, right object.Object) (object.Object, error) {
+	switch op {
+	case "??":
+		if left == object.NULL {
+			return right, nil
+		}
+		return left, nil
+	case "==":
+		return object.NewBoolean(equals(left, right)), nil
+	case "!=":
+		return object.NewBoolean(!equals(left, right)), nil
+	case "+":
+		if left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ {
+			return object.NewString(left.Value().(string) + right.Value().(string)), nil
+		}
+		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
+			return nil, fmt.Errorf("invalid operand(s) for \"+\"")
+		}
+		return object.NewNumber(left.Value().(float64) + right.Value().(float64)), nil
+	case "-":
+		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
+			return nil, fmt.Errorf("invalid operand(s) for \"-\"")
+		}
+		return object.NewNumber(left.Value().(float64) - right.Value().(float64)), nil
+	case "*":
+		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
+			return nil, fmt.Errorf("invalid operand(s) for \"*\"")
+		}
+		return object.NewNumber(left.Value().(float64) * right.Value().(float64)), nil
+	case "/":
+		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
+			return nil, fmt.Errorf("invalid operand(s) for \"/\"")
+		}
+		if right.Value().(float64) == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return object.NewNumber(left.Value().(float64) / right.Value().(float64)), nil
+	case ">":
+		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
+			return nil, fmt.Errorf("invalid operand(s) for \">\"")
+		}
+		return object.NewBoolean(left.Value().(float64) > right.Value().(float64)), nil
+	case "<":
+		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
+			return nil, fmt.Errorf("invalid operand(s) for \"<\"")
+		}
+		return object.NewBoolean(left.Value().(float64) < right.Value().(float64)), nil
+	default:
+		return nil, fmt.Errorf("invalid operator for binary expression %s", op)
+	}
+}
+
+func equals(left, right object.Object) bool {
+	return deepEquals(left, right, map[[2]object.Object]bool{})
+}
+
+// deepEquals compares left and right by value, recording the instances and
+// enum values being compared in active. A pair met again while comparing
+// it is a cycle, which is taken as equal so the comparison ends.
+func deepEquals(left, right object.Object, active map[[2]object.Object]bool) bool {
+	if left.Type() != right.Type() {
+		return false
+	}
+
+	switch left.Type() {
+	case object.NUMBER_OBJ, object.STRING_OBJ, object.BOOLEAN_OBJ, object.NULL_OBJ:
+		return left.Value() == right.Value()
+	case object.RESULT_OBJ:
+		l, r := left.(*object.Result), right.(*object.Result)
+		return l.IsOk() == r.IsOk() && deepEquals(l.Unwrap(), r.Unwrap(), active)
+	case object.INSTANCE_OBJ:
+		l, r := left.(*object.Instance), right.(*object.Instance)
+		if l == r {
+			return true
+		}
+		if l.Struct() != r.Struct() {
+			return false
+		}
+
+		pair := [2]object.Object{l, r}
+		if active[pair] {
+			return true
+		}
+		active[pair] = true
+		defer delete(active, pair)
+
+		for _, field := range l.Struct().Fields() {
+			lv, _ := l.Get(field)
+			rv, _ := r.Get(field)
+			if !deepEquals(lv, rv, active) {
+				return false
+			}
+		}
+		return true
+	case object.ENUM_VALUE_OBJ:
+		l, r := left.(*object.EnumValue), right.(*object.EnumValue)
+		if l == r {
+			return true
+		}
+		if l.Variant() != r.Variant() {
+			return false
+		}
+
+		pair := [2]object.Object{l, r}
+		if active[pair] {
+			return true
+		}
+		active[pair] = true
+		defer delete(active, pair)
+
+		for i, value := range l.Values() {
+			if !deepEquals(value, r.Values()[i], active) {
+				return false
+			}
+		}
+		return true
+	default:
+		return left == right
+	}
+}
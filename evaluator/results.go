@@ -0,0 +1,118 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/slinky55/milo/ast"
+	"github.com/slinky55/milo/object"
+)
+
+func init() {
+	register(&object.Builtin{
+		Name:   "Ok",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.ANY},
+		Doc:    "Ok(value) returns a successful result holding value.",
+		Fn:     Ok,
+	})
+	register(&object.Builtin{
+		Name:   "Err",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.ANY},
+		Doc:    "Err(reason) returns a failed result holding reason, usually an error.",
+		Fn:     Err,
+	})
+	register(&object.Builtin{
+		Name:   "isOk",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.RESULT_OBJ},
+		Doc:    "isOk(result) reports whether result is Ok.",
+		Fn:     IsOk,
+	})
+	register(&object.Builtin{
+		Name:   "unwrap",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.RESULT_OBJ},
+		Doc:    "unwrap(result) returns the value of an Ok result. For an Err it throws the error held, or one describing the result.",
+		Fn:     Unwrap,
+	})
+	register(&object.Builtin{
+		Name:   "unwrapOr",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.RESULT_OBJ, object.ANY},
+		Doc:    "unwrapOr(result, default) returns the value of an Ok result, or default for an Err.",
+		Fn:     UnwrapOr,
+	})
+}
+
+func Ok(host object.Host, args ...object.Object) (object.Object, error) {
+	return object.NewOk(args[0]), nil
+}
+
+func Err(host object.Host, args ...object.Object) (object.Object, error) {
+	return object.NewErr(args[0]), nil
+}
+
+func IsOk(host object.Host, args ...object.Object) (object.Object, error) {
+	return object.NewBoolean(args[0].(*object.Result).IsOk()), nil
+}
+
+func Unwrap(host object.Host, args ...object.Object) (object.Object, error) {
+	result := args[0].(*object.Result)
+	if result.IsOk() {
+		return result.Unwrap(), nil
+	}
+
+	if err, ok := result.Unwrap().(*object.Error); ok {
+		return nil, err
+	}
+	return nil, fmt.Errorf("unwrap: called on %s", result.ToString())
+}
+
+func UnwrapOr(host object.Host, args ...object.Object) (object.Object, error) {
+	result := args[0].(*object.Result)
+	if result.IsOk() {
+		return result.Unwrap(), nil
+	}
+	return args[1], nil
+}
+
+// evalPropagateExpr evaluates expr?, which unwraps an Ok result and
+// returns an Err result from the running function. Builtins like fs.readFile
+// report failures as error values, so an error returns Err holding it.
+func (e *Evaluator) evalPropagateExpr(expr *ast.PropagateExpr, env *object.Environment) (object.Object, error) {
+	value, err := e.evalExpression(expr.Expr, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := value.(type) {
+	case *object.Result:
+		if !value.IsOk() {
+			return nil, &earlyReturn{value: value}
+		}
+		return value.Unwrap(), nil
+	case *object.Error:
+		return nil, &earlyReturn{value: object.NewErr(value)}
+	default:
+		return nil, errorAt(expr.Token, "? expects a result, found %s", typeOf(value))
+	}
+}
+
+// earlyReturn carries the Err result of a ? up to the enclosing function
+// call, through the expressions it may be nested in. It is not an error a
+// program can catch.
+type earlyReturn struct {
+	value object.Object
+}
+
+func (er *earlyReturn) Error() string { return "returned " + er.value.ToString() }
+
+// uncaught returns the error raised when a ? fails outside any function,
+// where there is nothing to return its Err result from: the error the
+// result holds, or one describing the result.
+func (er *earlyReturn) uncaught() error {
+	if err, ok := er.value.(*object.Result).Unwrap().(*object.Error); ok {
+		return err
+	}
+	return fmt.Errorf("uncaught %s", er.value.ToString())
+}
@@ -0,0 +1,220 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/slinky55/milo/object"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	registerModule("strings", newStringsModule)
+}
+
+// newStringsModule builds the strings module. Positions and lengths are
+// counted in characters (runes), not bytes.
+func newStringsModule(e *Evaluator) *object.Module {
+	m := object.NewModule("strings")
+
+	unary := []struct {
+		name string
+		doc  string
+		fn   func(string) string
+	}{
+		{"trim", "strings.trim(s) returns s without leading and trailing white space.", strings.TrimSpace},
+		{"upper", "strings.upper(s) returns s in upper case.", strings.ToUpper},
+		{"lower", "strings.lower(s) returns s in lower case.", strings.ToLower},
+	}
+	for _, u := range unary {
+		exportBuiltin(m, &object.Builtin{
+			Name:   "strings." + u.name,
+			Arity:  object.Fixed(1),
+			Params: []object.ObjectType{object.STRING_OBJ},
+			Doc:    u.doc,
+			Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+				return object.NewString(u.fn(str(args[0]))), nil
+			},
+		})
+	}
+
+	predicates := []struct {
+		name string
+		doc  string
+		fn   func(string, string) bool
+	}{
+		{"contains", "strings.contains(s, sub) reports whether sub is within s.", strings.Contains},
+		{"startsWith", "strings.startsWith(s, prefix) reports whether s begins with prefix.", strings.HasPrefix},
+		{"endsWith", "strings.endsWith(s, suffix) reports whether s ends with suffix.", strings.HasSuffix},
+	}
+	for _, p := range predicates {
+		exportBuiltin(m, &object.Builtin{
+			Name:   "strings." + p.name,
+			Arity:  object.Fixed(2),
+			Params: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ},
+			Doc:    p.doc,
+			Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+				return object.NewBoolean(p.fn(str(args[0]), str(args[1]))), nil
+			},
+		})
+	}
+
+	exportBuiltin(m, &object.Builtin{
+		Name:   "strings.split",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ},
+		Doc:    "strings.split(s, sep) returns the parts of s between each sep. An empty sep splits s into characters.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			return stringArray(strings.Split(str(args[0]), str(args[1]))), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "strings.join",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.ARRAY_OBJ, object.STRING_OBJ},
+		Doc:    "strings.join(values, sep) returns the values as text separated by sep.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			var parts []string
+			for _, el := range args[0].(*object.Array).Elements() {
+				parts = append(parts, toString(el))
+			}
+			return object.NewString(strings.Join(parts, str(args[1]))), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "strings.replace",
+		Arity:  object.Fixed(3),
+		Params: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ, object.STRING_OBJ},
+		Doc:    "strings.replace(s, old, new) returns s with every old replaced by new.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			return object.NewString(strings.ReplaceAll(str(args[0]), str(args[1]), str(args[2]))), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "strings.indexOf",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ},
+		Doc:    "strings.indexOf(s, sub) returns the position of the first sub in s, or -1.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			s := str(args[0])
+
+			i := strings.Index(s, str(args[1]))
+			if i > 0 {
+				i = utf8.RuneCountInString(s[:i])
+			}
+			return object.NewNumber(float64(i)), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "strings.repeat",
+		Arity:  object.Fixed(2),
+		Params: []object.ObjectType{object.STRING_OBJ, object.NUMBER_OBJ},
+		Doc:    "strings.repeat(s, n) returns n copies of s.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			n, ok := integer(args[1])
+			if !ok || n < 0 {
+				return nil, fmt.Errorf("strings.repeat: argument 2 must be a non-negative integer")
+			}
+
+			s := str(args[0])
+			if n > 0 && len(s) > maxLength/n {
+				return nil, fmt.Errorf("strings.repeat: result exceeds the limit of %d bytes", maxLength)
+			}
+			return object.NewString(strings.Repeat(s, n)), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "strings.padLeft",
+		Arity:  object.Optional(2, 3),
+		Params: []object.ObjectType{object.STRING_OBJ, object.NUMBER_OBJ, object.STRING_OBJ},
+		Doc:    "strings.padLeft(s, width, pad?) prefixes s with pad, a space by default, until it is width characters long.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			padding, err := padding("strings.padLeft", args)
+			if err != nil {
+				return nil, err
+			}
+			return object.NewString(padding + str(args[0])), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "strings.padRight",
+		Arity:  object.Optional(2, 3),
+		Params: []object.ObjectType{object.STRING_OBJ, object.NUMBER_OBJ, object.STRING_OBJ},
+		Doc:    "strings.padRight(s, width, pad?) suffixes s with pad, a space by default, until it is width characters long.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			padding, err := padding("strings.padRight", args)
+			if err != nil {
+				return nil, err
+			}
+			return object.NewString(str(args[0]) + padding), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "strings.chars",
+		Arity:  object.Fixed(1),
+		Params: []object.ObjectType{object.STRING_OBJ},
+		Doc:    "strings.chars(s) returns the characters of s.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			var chars []string
+			for _, r := range str(args[0]) {
+				chars = append(chars, string(r))
+			}
+			return stringArray(chars), nil
+		},
+	})
+	exportBuiltin(m, &object.Builtin{
+		Name:   "strings.format",
+		Arity:  object.Variadic(1),
+		Params: []object.ObjectType{object.STRING_OBJ, object.ANY},
+		Doc:    "strings.format(format, args...) returns args formatted according to format, like sprintf.",
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			s, err := Format(str(args[0]), args[1:]...)
+			if err != nil {
+				return nil, fmt.Errorf("strings.format: %w", err)
+			}
+			return object.NewString(s), nil
+		},
+	})
+
+	return m
+}
+
+func str(obj object.Object) string {
+	return obj.Value().(string)
+}
+
+func stringArray(values []string) *object.Array {
+	var elements []object.Object
+	for _, v := range values {
+		elements = append(elements, object.NewString(v))
+	}
+	return object.NewArray(elements)
+}
+
+// padding returns the text padLeft and padRight add to their first
+// argument, repeating the pad and cutting it to the missing width.
+func padding(name string, args []object.Object) (string, error) {
+	width, ok := integer(args[1])
+	if !ok {
+		return "", fmt.Errorf("%s: argument 2 must be an integer", name)
+	}
+
+	pad := " "
+	if len(args) > 2 {
+		pad = str(args[2])
+	}
+	if pad == "" {
+		return "", fmt.Errorf("%s: argument 3 must not be empty", name)
+	}
+
+	missing := width - utf8.RuneCountInString(str(args[0]))
+	if missing <= 0 {
+		return "", nil
+	}
+	if missing > maxLength {
+		return "", fmt.Errorf("%s: width exceeds the limit of %d characters", name, maxLength)
+	}
+
+	n := utf8.RuneCountInString(pad)
+	runes := []rune(strings.Repeat(pad, (missing+n-1)/n))
+	return string(runes[:missing]), nil
+}
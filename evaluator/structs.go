@@ -0,0 +1,105 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/slinky55/milo/ast"
+	"github.com/slinky55/milo/object"
+)
+
+// newStruct returns the struct declared by stmt.
+func newStruct(stmt *ast.StructStatement) *object.Struct {
+	fields := make([]string, len(stmt.Fields))
+	for i, field := range stmt.Fields {
+		fields[i] = field.Value
+	}
+	return object.NewStruct(stmt.Name.Value, fields)
+}
+
+// construct creates an instance of s from the field values in args.
+func construct(s *object.Struct, args []object.Object) (object.Object, error) {
+	values, err := fieldValues(s.Name(), s.Fields(), args)
+	if err != nil {
+		return nil, err
+	}
+	return object.NewInstance(s, values), nil
+}
+
+// fieldValues checks that args, passed to the constructor called name,
+// hold a value for each of fields. Nil arguments are fields that were not
+// given a value.
+func fieldValues(name string, fields []string, args []object.Object) ([]object.Object, error) {
+	if len(args) > len(fields) {
+		return nil, fmt.Errorf("%s: wrong number of arguments: expected %d, found %d", name, len(fields), len(args))
+	}
+
+	values := make([]object.Object, len(fields))
+	for i, field := range fields {
+		if i >= len(args) || args[i] == nil {
+			return nil, fmt.Errorf("%s: missing field %s", name, field)
+		}
+		values[i] = args[i]
+	}
+	return values, nil
+}
+
+// evalAssign evaluates target.field = value, which sets a field of a
+// struct instance.
+func (e *Evaluator) evalAssign(stmt *ast.AssignStatement, env *object.Environment) error {
+	obj, err := e.evalExpression(stmt.Target.Object, env)
+	if err != nil {
+		return err
+	}
+
+	value, err := e.evalExpression(stmt.Value, env)
+	if err != nil {
+		return err
+	}
+
+	name := stmt.Target.Property.Value
+
+	inst, ok := obj.(*object.Instance)
+	if !ok {
+		return errorAt(stmt.Token, "cannot assign to member %s of %s", name, typeOf(obj))
+	}
+
+	if !inst.Set(name, value) {
+		return errorAt(stmt.Target.Property.Token, "%s has no field %s", inst.Struct().Name(), name)
+	}
+	return nil
+}
+
+// evalImpl defines the methods of stmt on the struct it names. They are
+// closures over env, like functions defined there.
+func (e *Evaluator) evalImpl(stmt *ast.ImplStatement, env *object.Environment) error {
+	name := stmt.Type.Value
+
+	value, ok := e.resolve(name, env)
+	if !ok {
+		return errorAt(stmt.Type.Token, "unknown struct %s", name)
+	}
+
+	s, ok := value.(*object.Struct)
+	if !ok {
+		return errorAt(stmt.Type.Token, "cannot impl %s: not a struct, found %s", name, typeOf(value))
+	}
+
+	for _, method := range stmt.Methods {
+		if s.FieldIndex(method.Name) >= 0 {
+			return errorAt(method.Token, "%s has a field %s, which a method cannot replace", name, method.Name)
+		}
+		s.SetMethod(method.Name, object.NewFunction(name+"."+method.Name, method.Body.Statements, method.Parameters, env))
+	}
+	return nil
+}
+
+// bindMethod returns a function calling method with inst as self, for a
+// method accessed without calling it, as in map(xs, p.dist).
+func bindMethod(inst *object.Instance, method *object.Function) *object.Builtin {
+	return &object.Builtin{
+		Name:  method.Name(),
+		Arity: object.Variadic(0),
+		Fn: func(host object.Host, args ...object.Object) (object.Object, error) {
+			return host.Apply(method, append([]object.Object{inst}, args...)...)
+		},
+	}
+}
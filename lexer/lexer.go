@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"github.com/slinky55/milo/token"
+	"strings"
 	"unicode"
 )
 
@@ -10,6 +11,9 @@ type Lexer struct {
 	charPos int
 	readPos int
 	char    byte
+
+	line   int
+	column int
 }
 
 func New(input string) *Lexer {
@@ -18,17 +22,40 @@ func New(input string) *Lexer {
 		charPos: 0,
 		readPos: 0,
 		char:    0,
+		line:    1,
 	}
 	l.advance()
 	return l
 }
 
 func (l *Lexer) NextToken() *token.Token {
-	var tk *token.Token
+	l.skipWhitespace()
 
-	for l.char == ' ' || l.char == '\t' || l.char == '\n' || l.char == '\r' {
-		l.advance()
+	line, column := l.line, l.column
+	tk := l.readToken()
+	tk.Line, tk.Column = line, column
+
+	return tk
+}
+
+// skipWhitespace skips over whitespace and comments.
+func (l *Lexer) skipWhitespace() {
+	for {
+		switch {
+		case l.char == ' ' || l.char == '\t' || l.char == '\n' || l.char == '\r':
+			l.advance()
+		case l.char == '/' && l.peek() == '/':
+			for l.char != '\n' && l.char != 0 {
+				l.advance()
+			}
+		default:
+			return
+		}
 	}
+}
+
+func (l *Lexer) readToken() *token.Token {
+	var tk *token.Token
 
 	switch l.char {
 	case '=':
@@ -37,6 +64,11 @@ func (l *Lexer) NextToken() *token.Token {
 			l.advance()
 			literal := first + string(l.char)
 			tk = token.New(token.EQUALS, literal)
+		} else if l.peek() == '>' {
+			first := string(l.char)
+			l.advance()
+			literal := first + string(l.char)
+			tk = token.New(token.ARROW, literal)
 		} else {
 			tk = token.New(token.ASSIGN, string(l.char))
 		}
@@ -48,6 +80,47 @@ func (l *Lexer) NextToken() *token.Token {
 		tk = token.New(token.LBRACE, string(l.char))
 	case '}':
 		tk = token.New(token.RBRACE, string(l.char))
+	case '[':
+		tk = token.New(token.LBRACKET, string(l.char))
+	case ']':
+		tk = token.New(token.RBRACKET, string(l.char))
+	case ':':
+		tk = token.New(token.COLON, string(l.char))
+	case '.':
+		if l.peek() == '.' {
+			first := string(l.char)
+			l.advance()
+			literal := first + string(l.char)
+			if l.peek() == '.' {
+				l.advance()
+				tk = token.New(token.ELLIPSIS, literal+string(l.char))
+			} else {
+				tk = token.New(token.DOTDOT, literal)
+			}
+		} else {
+			tk = token.New(token.DOT, string(l.char))
+		}
+	case '|':
+		if l.peek() == '>' {
+			first := string(l.char)
+			l.advance()
+			tk = token.New(token.PIPELINE, first+string(l.char))
+		} else {
+			tk = token.New(token.PIPE, string(l.char))
+		}
+	case '?':
+		if l.peek() == '.' || l.peek() == '?' {
+			first := string(l.char)
+			l.advance()
+			literal := first + string(l.char)
+			if l.char == '.' {
+				tk = token.New(token.QDOT, literal)
+			} else {
+				tk = token.New(token.NULLISH, literal)
+			}
+		} else {
+			tk = token.New(token.QUESTION, string(l.char))
+		}
 	case '(':
 		tk = token.New(token.LPAREN, string(l.char))
 	case ')':
@@ -73,14 +146,7 @@ func (l *Lexer) NextToken() *token.Token {
 	case '*':
 		tk = token.New(token.MULTIPLY, string(l.char))
 	case '/':
-		if l.peek() == '/' {
-			l.advance()
-			for l.peek() != '\n' {
-				l.advance()
-			}
-		} else {
-			tk = token.New(token.DIVIDE, string(l.char))
-		}
+		tk = token.New(token.DIVIDE, string(l.char))
 	case '!':
 		if l.peek() == '=' {
 			first := string(l.char)
@@ -97,19 +163,11 @@ func (l *Lexer) NextToken() *token.Token {
 	case 0:
 		tk = token.New(token.EOF, "")
 	case '"':
-		l.advance()
-		start := l.charPos
-		for l.char != '"' {
-			l.advance()
-		}
-
-		value := l.input[start:l.charPos]
-
-		tk = token.New(token.STRING, value)
+		tk = l.readString()
 	default:
-		if unicode.IsLetter(rune(l.char)) {
+		if unicode.IsLetter(rune(l.char)) || l.char == '_' {
 			start := l.charPos
-			for unicode.IsLetter(rune(l.char)) || l.char == '_' {
+			for unicode.IsLetter(rune(l.char)) || unicode.IsNumber(rune(l.char)) || l.char == '_' {
 				l.advance()
 			}
 			literal := l.input[start:l.charPos]
@@ -124,6 +182,15 @@ func (l *Lexer) NextToken() *token.Token {
 			for unicode.IsNumber(rune(l.char)) {
 				l.advance()
 			}
+
+			// a fraction needs a digit after the dot, so 1..5 and
+			// 5.method() keep their meaning
+			if l.char == '.' && unicode.IsDigit(rune(l.peek())) {
+				l.advance()
+				for unicode.IsNumber(rune(l.char)) {
+					l.advance()
+				}
+			}
 			literal := l.input[start:l.charPos]
 
 			tk = token.New(token.NUMBER, literal)
@@ -137,7 +204,50 @@ func (l *Lexer) NextToken() *token.Token {
 	return tk
 }
 
+// readString reads a string literal, replacing the escape sequences \n, \t,
+// \r, \" and \\ with the characters they stand for. Any other backslash is
+// kept as written. A literal with no closing quote is ILLEGAL.
+func (l *Lexer) readString() *token.Token {
+	var value strings.Builder
+
+	l.advance()
+	for l.char != '"' {
+		switch l.char {
+		case 0:
+			return token.New(token.ILLEGAL, "unterminated string")
+		case '\\':
+			l.advance()
+			switch l.char {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			case 'r':
+				value.WriteByte('\r')
+			case '"', '\\':
+				value.WriteByte(l.char)
+			case 0:
+				return token.New(token.ILLEGAL, "unterminated string")
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(l.char)
+			}
+		default:
+			value.WriteByte(l.char)
+		}
+		l.advance()
+	}
+
+	return token.New(token.STRING, value.String())
+}
+
 func (l *Lexer) advance() {
+	if l.char == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
 	if l.readPos >= len(l.input) {
 		l.char = 0
 	} else {
@@ -323,3 +323,265 @@ func TestString(t *testing.T) {
 		t.Errorf("expected EOF, found %s", last.Type)
 	}
 }
+
+func TestStringEscapes(t *testing.T) {
+	input := `"a\nb" "tab\there" "cr\r" "say \"hi\"" "back\\slash" "\d" "open`
+	l := New(input)
+
+	expected := []token.Token{
+		{Type: token.STRING, Literal: "a\nb"},
+		{Type: token.STRING, Literal: "tab\there"},
+		{Type: token.STRING, Literal: "cr\r"},
+		{Type: token.STRING, Literal: "say \"hi\""},
+		{Type: token.STRING, Literal: "back\\slash"},
+		{Type: token.STRING, Literal: "\\d"},
+		{Type: token.ILLEGAL, Literal: "unterminated string"},
+	}
+
+	for _, e := range expected {
+		a := l.NextToken()
+
+		if a.Type != e.Type {
+			t.Errorf("expected type %s, found %s", e.Type, a.Type)
+		}
+
+		if a.Literal != e.Literal {
+			t.Errorf("expected literal %q, found %q", e.Literal, a.Literal)
+		}
+	}
+
+	last := l.NextToken()
+	if last.Type != token.EOF {
+		t.Errorf("expected EOF, found %s", last.Type)
+	}
+}
+
+func TestUnterminatedEscape(t *testing.T) {
+	l := New(`"trailing\`)
+
+	tk := l.NextToken()
+	if tk.Type != token.ILLEGAL || tk.Literal != "unterminated string" {
+		t.Errorf("expected ILLEGAL unterminated string, found %s %q", tk.Type, tk.Literal)
+	}
+
+	last := l.NextToken()
+	if last.Type != token.EOF {
+		t.Errorf("expected EOF, found %s", last.Type)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	input := "let a = 5;\n// comment\n  foo(\"x\")"
+	l := New(input)
+
+	expected := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"let", 1, 1},
+		{"a", 1, 5},
+		{"=", 1, 7},
+		{"5", 1, 9},
+		{";", 1, 10},
+		{"foo", 3, 3},
+		{"(", 3, 6},
+		{"x", 3, 7},
+		{")", 3, 10},
+	}
+
+	for _, e := range expected {
+		a := l.NextToken()
+
+		if a.Literal != e.literal {
+			t.Errorf("expected literal %s, found %s", e.literal, a.Literal)
+		}
+
+		if a.Line != e.line || a.Column != e.column {
+			t.Errorf("%s: expected position %d:%d, found %d:%d", e.literal, e.line, e.column, a.Line, a.Column)
+		}
+	}
+}
+
+func TestAccessTokens(t *testing.T) {
+	input := "a[0]: b.c"
+	l := New(input)
+
+	expected := []*token.Token{
+		token.New(token.IDENT, "a"),
+		token.New(token.LBRACKET, "["),
+		token.New(token.NUMBER, "0"),
+		token.New(token.RBRACKET, "]"),
+		token.New(token.COLON, ":"),
+		token.New(token.IDENT, "b"),
+		token.New(token.DOT, "."),
+		token.New(token.IDENT, "c"),
+	}
+
+	for _, e := range expected {
+		a := l.NextToken()
+
+		if a.Type != e.Type {
+			t.Errorf("expected type %s, found %s", e.Type, a.Type)
+		}
+
+		if a.Literal != e.Literal {
+			t.Errorf("expected literal %s, found %s", e.Literal, a.Literal)
+		}
+	}
+
+	last := l.NextToken()
+	if last.Type != token.EOF {
+		t.Errorf("expected EOF, found %s", last.Type)
+	}
+}
+
+func TestNullSafeTokens(t *testing.T) {
+	input := "a ?? null; b?.c d?.[1] ?"
+	l := New(input)
+
+	expected := []*token.Token{
+		token.New(token.IDENT, "a"),
+		token.New(token.NULLISH, "??"),
+		token.New(token.NULL, "null"),
+		token.New(token.SEMICOLON, ";"),
+		token.New(token.IDENT, "b"),
+		token.New(token.QDOT, "?."),
+		token.New(token.IDENT, "c"),
+		token.New(token.IDENT, "d"),
+		token.New(token.QDOT, "?."),
+		token.New(token.LBRACKET, "["),
+		token.New(token.NUMBER, "1"),
+		token.New(token.RBRACKET, "]"),
+		token.New(token.QUESTION, "?"),
+	}
+
+	for _, e := range expected {
+		a := l.NextToken()
+
+		if a.Type != e.Type {
+			t.Errorf("expected type %s, found %s", e.Type, a.Type)
+		}
+
+		if a.Literal != e.Literal {
+			t.Errorf("expected literal %s, found %s", e.Literal, a.Literal)
+		}
+	}
+
+	last := l.NextToken()
+	if last.Type != token.EOF {
+		t.Errorf("expected EOF, found %s", last.Type)
+	}
+}
+
+func TestMatchTokens(t *testing.T) {
+	input := "match (x) { 1..9 => a, \"b\" | _c2 => d }"
+	l := New(input)
+
+	expected := []*token.Token{
+		token.New(token.MATCH, "match"),
+		token.New(token.LPAREN, "("),
+		token.New(token.IDENT, "x"),
+		token.New(token.RPAREN, ")"),
+		token.New(token.LBRACE, "{"),
+		token.New(token.NUMBER, "1"),
+		token.New(token.DOTDOT, ".."),
+		token.New(token.NUMBER, "9"),
+		token.New(token.ARROW, "=>"),
+		token.New(token.IDENT, "a"),
+		token.New(token.COMMA, ","),
+		token.New(token.STRING, "b"),
+		token.New(token.PIPE, "|"),
+		token.New(token.IDENT, "_c2"),
+		token.New(token.ARROW, "=>"),
+		token.New(token.IDENT, "d"),
+		token.New(token.RBRACE, "}"),
+	}
+
+	for _, e := range expected {
+		a := l.NextToken()
+
+		if a.Type != e.Type {
+			t.Errorf("expected type %s, found %s", e.Type, a.Type)
+		}
+
+		if a.Literal != e.Literal {
+			t.Errorf("expected literal %s, found %s", e.Literal, a.Literal)
+		}
+	}
+
+	last := l.NextToken()
+	if last.Type != token.EOF {
+		t.Errorf("expected EOF, found %s", last.Type)
+	}
+}
+
+func TestRestAndPipeline(t *testing.T) {
+	input := "[a, ...b] 1..2 x |> f"
+	l := New(input)
+
+	expected := []*token.Token{
+		token.New(token.LBRACKET, "["),
+		token.New(token.IDENT, "a"),
+		token.New(token.COMMA, ","),
+		token.New(token.ELLIPSIS, "..."),
+		token.New(token.IDENT, "b"),
+		token.New(token.RBRACKET, "]"),
+		token.New(token.NUMBER, "1"),
+		token.New(token.DOTDOT, ".."),
+		token.New(token.NUMBER, "2"),
+		token.New(token.IDENT, "x"),
+		token.New(token.PIPELINE, "|>"),
+		token.New(token.IDENT, "f"),
+	}
+
+	for _, e := range expected {
+		a := l.NextToken()
+
+		if a.Type != e.Type {
+			t.Errorf("expected type %s, found %s", e.Type, a.Type)
+		}
+
+		if a.Literal != e.Literal {
+			t.Errorf("expected literal %s, found %s", e.Literal, a.Literal)
+		}
+	}
+
+	last := l.NextToken()
+	if last.Type != token.EOF {
+		t.Errorf("expected EOF, found %s", last.Type)
+	}
+}
+
+func TestDecimalNumbers(t *testing.T) {
+	input := "3.14 1..2 5.f 0.5"
+	l := New(input)
+
+	expected := []*token.Token{
+		token.New(token.NUMBER, "3.14"),
+		token.New(token.NUMBER, "1"),
+		token.New(token.DOTDOT, ".."),
+		token.New(token.NUMBER, "2"),
+		token.New(token.NUMBER, "5"),
+		token.New(token.DOT, "."),
+		token.New(token.IDENT, "f"),
+		token.New(token.NUMBER, "0.5"),
+	}
+
+	for _, e := range expected {
+		a := l.NextToken()
+
+		if a.Type != e.Type {
+			t.Errorf("expected type %s, found %s", e.Type, a.Type)
+		}
+
+		if a.Literal != e.Literal {
+			t.Errorf("expected literal %s, found %s", e.Literal, a.Literal)
+		}
+	}
+
+	last := l.NextToken()
+	if last.Type != token.EOF {
+		t.Errorf("expected EOF, found %s", last.Type)
+	}
+}
@@ -0,0 +1,168 @@
+// Package milo embeds the Milo interpreter in Go programs.
+package milo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/slinky55/milo/analyzer"
+	"github.com/slinky55/milo/ast"
+	"github.com/slinky55/milo/evaluator"
+	"github.com/slinky55/milo/lexer"
+	"github.com/slinky55/milo/object"
+	"github.com/slinky55/milo/optimizer"
+	"github.com/slinky55/milo/parser"
+	"io"
+	"os"
+	"strings"
+)
+
+// Interpreter runs Milo source code. Globals defined by one call to Run are
+// visible to later calls. An Interpreter is not safe for concurrent use.
+type Interpreter struct {
+	eval *evaluator.Evaluator
+}
+
+func NewInterpreter() *Interpreter {
+	i := &Interpreter{
+		eval: evaluator.New(nil),
+	}
+	i.eval.Compile = i.compile
+	return i
+}
+
+// SetOutput sets where scripts write their standard output and standard
+// error. They default to os.Stdout and os.Stderr.
+func (i *Interpreter) SetOutput(stdout, stderr io.Writer) {
+	i.eval.SetOutput(stdout, stderr)
+}
+
+// SetMaxDepth limits how many non-tail function calls may be nested.
+func (i *Interpreter) SetMaxDepth(depth int) {
+	i.eval.MaxDepth = depth
+}
+
+// SetSearchPath sets the directories searched for imported modules that
+// are not found relative to the importing file.
+func (i *Interpreter) SetSearchPath(dirs ...string) {
+	i.eval.SearchPath = dirs
+}
+
+// SetFileRoot sets the directory scripts may access through the fs module.
+// File access is disabled until a root is set.
+func (i *Interpreter) SetFileRoot(dir string) {
+	i.eval.FileRoot = dir
+}
+
+// Run parses, optimizes and evaluates source, returning the value of its
+// last expression statement. Analyzer warnings are written to standard
+// error. Imports are resolved relative to the working directory. An error
+// the program does not catch is returned as an *object.Error, whose Trace
+// lists the functions it was raised in.
+func (i *Interpreter) Run(ctx context.Context, source string) (object.Object, error) {
+	program, err := i.compile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	i.eval.Program = program
+	return i.eval.Run(ctx, program)
+}
+
+// RunFile runs the program in the file at path like Run. Imports are
+// resolved relative to the file.
+func (i *Interpreter) RunFile(ctx context.Context, path string) (object.Object, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := i.compile(string(source))
+	if err != nil {
+		return nil, err
+	}
+	program.File = path
+
+	i.eval.Program = program
+	return i.eval.Run(ctx, program)
+}
+
+// compile compiles source, reporting analyzer warnings to the script's
+// standard error.
+func (i *Interpreter) compile(source string) (*ast.Program, error) {
+	return Compile(source, i.eval.Stderr())
+}
+
+// Compile parses, analyzes and optimizes source into a program ready to be
+// evaluated. Analyzer warnings are written to warnings; syntax errors are
+// returned.
+func Compile(source string, warnings io.Writer) (*ast.Program, error) {
+	l := lexer.New(source)
+	p := parser.New(l)
+
+	program := p.Parse()
+	if len(p.Errors) > 0 {
+		return nil, errors.New(strings.Join(p.Errors, "\n"))
+	}
+
+	a := analyzer.New()
+	a.Analyze(program)
+	for _, warning := range a.Warnings {
+		fmt.Fprintln(warnings, warning)
+	}
+
+	o := optimizer.New()
+	return o.Optimize(program), nil
+}
+
+// Call calls the global function name. Arguments are converted with ToObject.
+func (i *Interpreter) Call(name string, args ...any) (object.Object, error) {
+	fn, ok := i.eval.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown function: %s", name)
+	}
+
+	var objs []object.Object
+	for n, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument %d: %w", name, n+1, err)
+		}
+		objs = append(objs, obj)
+	}
+
+	return i.eval.Call(context.Background(), fn, objs...)
+}
+
+// Get returns the value of the global variable name.
+func (i *Interpreter) Get(name string) (object.Object, bool) {
+	return i.eval.Get(name)
+}
+
+// Set defines the global variable name, converting value with ToObject.
+func (i *Interpreter) Set(name string, value any) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	i.eval.Set(name, obj)
+	return nil
+}
+
+// Register makes the Go function fn callable from Milo as name. See Wrap
+// for the supported function signatures.
+func (i *Interpreter) Register(name string, fn any) error {
+	builtin, err := Wrap(name, fn)
+	if err != nil {
+		return err
+	}
+
+	i.eval.Define(builtin)
+	return nil
+}
+
+// Define makes the builtin b callable from Milo.
+func (i *Interpreter) Define(b *object.Builtin) {
+	i.eval.Define(b)
+}
@@ -0,0 +1,341 @@
+package milo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/slinky55/milo/object"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"1 + 2", 3.0},
+		{"\"foo\" + \"bar\"", "foobar"},
+		{"let x = 5; x > 2", true},
+		{"let x = 5;", nil},
+	}
+
+	for _, test := range tests {
+		i := NewInterpreter()
+
+		value, err := i.Run(context.Background(), test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if FromObject(value) != test.expected {
+			t.Errorf("%s: expected %v, found %v", test.input, test.expected, FromObject(value))
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []string{
+		"let = 5;",
+		"1 / 0",
+		"undefined + 1",
+	}
+
+	for _, test := range tests {
+		if _, err := NewInterpreter().Run(context.Background(), test); err == nil {
+			t.Errorf("%s: expected an error", test)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	var warnings bytes.Buffer
+
+	program, err := Compile("match (x) { true => 1 }; 2 * 3", &warnings)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if actual := program.Statements[1].ToString(); actual != "6" {
+		t.Errorf("expected the program to be optimized, found %s", actual)
+	}
+
+	expected := "analyzer warning: 1:1: non-exhaustive match: missing false\n"
+	if warnings.String() != expected {
+		t.Errorf("expected warnings %q, found %q", expected, warnings.String())
+	}
+
+	if _, err := Compile("let = 5;", &warnings); err == nil {
+		t.Errorf("expected a syntax error")
+	}
+
+	program, err = Compile("1 / 0", &warnings)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if actual := program.Statements[0].ToString(); actual != "(1 / 0)" {
+		t.Errorf("expected the division to be left to run, found %s", actual)
+	}
+}
+
+func TestRunErrorTrace(t *testing.T) {
+	i := NewInterpreter()
+
+	_, err := i.Run(context.Background(), "let check = fn (n) {\n  if (n < 0) { throw error(\"negative\", \"RangeError\"); }\n  n\n};\ncheck(-1) + 1")
+
+	var raised *object.Error
+	if !errors.As(err, &raised) {
+		t.Fatalf("expected an error value, found %v", err)
+	}
+
+	expected := "RangeError: negative\n    at check (line 2)\n    at <main> (line 5)"
+	if raised.Trace() != expected {
+		t.Errorf("expected %q, found %q", expected, raised.Trace())
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	i := NewInterpreter()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := i.Run(ctx, "let loop = fn (n) { loop(n + 1) }; loop(0)")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, found %v", err)
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	i := NewInterpreter()
+
+	if err := i.Set("limit", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := i.Run(context.Background(), "let double = limit * 2;"); err != nil {
+		t.Fatal(err)
+	}
+
+	value, ok := i.Get("double")
+	if !ok {
+		t.Fatal("double is not defined")
+	}
+
+	if FromObject(value) != 20.0 {
+		t.Errorf("expected 20, found %v", FromObject(value))
+	}
+
+	if err := i.Set("bad", struct{}{}); err == nil {
+		t.Error("expected an error converting a struct")
+	}
+}
+
+func TestConversion(t *testing.T) {
+	i := NewInterpreter()
+
+	config := map[string]any{
+		"name":  "milo",
+		"ports": []int{80, 443},
+		"debug": false,
+		"extra": nil,
+	}
+	if err := i.Set("config", config); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := i.Run(context.Background(), "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keys are sorted when converting a Go map
+	expected := `{"debug": false, "extra": null, "name": "milo", "ports": [80, 443]}`
+	if value.ToString() != expected {
+		t.Errorf("expected %s, found %s", expected, value.ToString())
+	}
+
+	back := map[string]any{
+		"name":  "milo",
+		"ports": []any{80.0, 443.0},
+		"debug": false,
+		"extra": nil,
+	}
+	if !reflect.DeepEqual(FromObject(value), back) {
+		t.Errorf("expected %v, found %v", back, FromObject(value))
+	}
+
+	value, err = i.Run(context.Background(), "{1: [\"a\"], true: {}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mixed := map[any]any{1.0: []any{"a"}, true: map[string]any{}}
+	if !reflect.DeepEqual(FromObject(value), mixed) {
+		t.Errorf("expected %v, found %v", mixed, FromObject(value))
+	}
+
+	if err := i.Set("bad", []any{1, struct{}{}}); err == nil || err.Error() != "bad: element 1: cannot convert struct {} to a milo value" {
+		t.Errorf("expected an error converting a struct element, found %v", err)
+	}
+}
+
+func TestConversionCycles(t *testing.T) {
+	m := object.NewMap()
+	a := object.NewArray([]object.Object{m})
+	m.Set(object.NewString("list"), a)
+
+	converted := FromObject(m).(map[string]any)
+	list := converted["list"].([]any)
+	if reflect.ValueOf(list[0]).Pointer() != reflect.ValueOf(converted).Pointer() {
+		t.Error("expected the converted map to contain itself")
+	}
+}
+
+func TestCall(t *testing.T) {
+	i := NewInterpreter()
+
+	if _, err := i.Run(context.Background(), "let greet = fn (name, n) { name + \"!\" };"); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := i.Call("greet", "hi", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if FromObject(value) != "hi!" {
+		t.Errorf("expected hi!, found %v", FromObject(value))
+	}
+
+	if _, err := i.Call("missing"); err == nil {
+		t.Error("expected an error calling an undefined function")
+	}
+
+	if _, err := i.Call("greet", "hi"); err == nil {
+		t.Error("expected an arity error")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	i := NewInterpreter()
+
+	err := i.Register("repeat", func(s string, n int) (string, error) {
+		if n < 0 {
+			return "", errors.New("negative count")
+		}
+		return strings.Repeat(s, n), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = i.Register("byte", func(n uint8) uint8 { return n })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = i.Register("sum", func(nums ...float64) float64 {
+		total := 0.0
+		for _, n := range nums {
+			total += n
+		}
+		return total
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected any
+		err      string
+	}{
+		{"repeat(\"ab\", 3)", "ababab", ""},
+		{"sum(1, 2, 3)", 6.0, ""},
+		{"sum()", 0.0, ""},
+		{"repeat(\"ab\")", nil, "repeat: wrong number of arguments: expected 2, found 1"},
+		{"repeat(3, 3)", nil, "repeat: argument 1 must be string"},
+		{"repeat(\"ab\", 1 / 2)", nil, "repeat: argument 2 must be an integer"},
+		{"repeat(\"ab\", -1)", nil, "repeat: negative count"},
+		{"repeat(\"ab\", 9223372036854775808)", nil, "repeat: argument 2 is out of range for int"},
+		{"byte(255)", 255.0, ""},
+		{"byte(-1)", nil, "byte: argument 1 must not be negative"},
+		{"byte(256)", nil, "byte: argument 1 is out of range for uint8"},
+		{"byte(0.5)", nil, "byte: argument 1 must be an integer"},
+		{"sum(1, true)", nil, "sum: argument 2 must be number"},
+	}
+
+	for _, test := range tests {
+		value, err := i.Run(context.Background(), test.input)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected error %q, found %v", test.input, test.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.input, err)
+			continue
+		}
+
+		if FromObject(value) != test.expected {
+			t.Errorf("%s: expected %v, found %v", test.input, test.expected, FromObject(value))
+		}
+	}
+
+	if err := i.Register("bad", func(ch chan int) {}); err == nil {
+		t.Error("expected an error registering an unsupported parameter type")
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	i := NewInterpreter()
+	i.SetOutput(&stdout, &stderr)
+
+	if _, err := i.Run(context.Background(), "printf(\"%s=%d\\n\", \"x\", 42);"); err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout.String() != "x=42\n" {
+		t.Errorf("expected output %q, found %q", "x=42\n", stdout.String())
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.milo":      "import \"shapes.milo\" as s; import \"greet.milo\" as g; g.greet(s.name)",
+		"shapes.milo":    "export let name = \"square\";",
+		"lib/greet.milo": "export let greet = x => \"hello \" + x;",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	i := NewInterpreter()
+	i.SetSearchPath(filepath.Join(dir, "lib"))
+
+	value, err := i.RunFile(context.Background(), filepath.Join(dir, "main.milo"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if FromObject(value) != "hello square" {
+		t.Errorf("expected hello square, found %v", FromObject(value))
+	}
+}
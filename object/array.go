@@ -0,0 +1,31 @@
+package object
+
+import "strings"
+
+type Array struct {
+	elements []Object
+}
+
+func NewArray(elements []Object) *Array { return &Array{elements: elements} }
+
+func (a *Array) ToString() string {
+	var elems []string
+	for _, el := range a.elements {
+		elems = append(elems, Repr(el))
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Value() any       { return a.elements }
+
+func (a *Array) Elements() []Object { return a.elements }
+func (a *Array) Len() int           { return len(a.elements) }
+
+// Get returns the element at index i, or NULL if i is out of range.
+func (a *Array) Get(i int) Object {
+	if i < 0 || i >= len(a.elements) {
+		return NULL
+	}
+	return a.elements[i]
+}
@@ -0,0 +1,145 @@
+package object
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ANY is used in Builtin.Params for parameters that accept every type.
+const ANY ObjectType = "ANY"
+
+// Arity is the number of arguments a builtin accepts. Max is negative for
+// variadic builtins.
+type Arity struct {
+	Min int
+	Max int
+}
+
+// Fixed is the arity of a builtin taking exactly n arguments.
+func Fixed(n int) Arity { return Arity{Min: n, Max: n} }
+
+// Optional is the arity of a builtin taking min required arguments followed
+// by optional ones, up to max in total.
+func Optional(min, max int) Arity { return Arity{Min: min, Max: max} }
+
+// Variadic is the arity of a builtin taking at least min arguments.
+func Variadic(min int) Arity { return Arity{Min: min, Max: -1} }
+
+// Accepts reports whether n arguments satisfy the arity.
+func (a Arity) Accepts(n int) bool {
+	return n >= a.Min && (a.Max < 0 || n <= a.Max)
+}
+
+func (a Arity) String() string {
+	switch {
+	case a.Max < 0:
+		return fmt.Sprintf("at least %d", a.Min)
+	case a.Min == a.Max:
+		return fmt.Sprintf("%d", a.Min)
+	default:
+		return fmt.Sprintf("%d to %d", a.Min, a.Max)
+	}
+}
+
+// Host is the interpreter running a builtin.
+type Host interface {
+	Stdout() io.Writer
+	Stderr() io.Writer
+
+	// Apply calls the function fn with args, so builtins can call
+	// functions passed to them.
+	Apply(fn Object, args ...Object) (Object, error)
+}
+
+type BuiltinFunction func(host Host, args ...Object) (Object, error)
+
+// Builtin is a function implemented in Go. Call checks the arguments
+// against Arity and Params before running Fn, so Fn can index and type
+// assert its arguments freely.
+type Builtin struct {
+	Name  string
+	Arity Arity
+
+	// Params holds the type of each parameter, or ANY. For variadic
+	// builtins the last type also applies to all remaining arguments.
+	// A nil Params disables type checking.
+	Params []ObjectType
+
+	Doc string
+	Fn  BuiltinFunction
+}
+
+func (b *Builtin) ToString() string { return "builtin " + b.Name }
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Value() any       { return b.Fn }
+
+func (b *Builtin) Call(host Host, args ...Object) (Object, error) {
+	if !b.Arity.Accepts(len(args)) {
+		return nil, fmt.Errorf("%s: wrong number of arguments: expected %s, found %d", b.Name, b.Arity, len(args))
+	}
+
+	for i, arg := range args {
+		expected, ok := b.paramType(i)
+		if !ok || expected == ANY {
+			continue
+		}
+
+		if !hasType(arg, expected) {
+			return nil, fmt.Errorf("%s: argument %d must be %s", b.Name, i+1, TypeName(expected))
+		}
+	}
+
+	result, err := b.Fn(host, args...)
+	if err == nil && result == nil {
+		result = NULL
+	}
+	return result, err
+}
+
+func (b *Builtin) paramType(i int) (ObjectType, bool) {
+	if len(b.Params) == 0 {
+		return "", false
+	}
+	if i >= len(b.Params) {
+		return b.Params[len(b.Params)-1], b.Arity.Max < 0
+	}
+	return b.Params[i], true
+}
+
+// hasType reports whether obj can be passed where type t is expected.
+// Builtins are accepted wherever a function is.
+func hasType(obj Object, t ObjectType) bool {
+	if obj == nil {
+		return false
+	}
+	if t == FUNC_OBJ {
+		return IsCallable(obj)
+	}
+	return obj.Type() == t
+}
+
+// IsCallable reports whether obj is a user defined or builtin function, or
+// a struct or enum variant, which is called to construct a value.
+func IsCallable(obj Object) bool {
+	switch obj := obj.(type) {
+	case *Function, *Builtin, *Struct:
+		return true
+	case *Variant:
+		return obj.unit == nil
+	default:
+		return false
+	}
+}
+
+// TypeName is the name of an object type as shown to Milo programs.
+func TypeName(t ObjectType) string {
+	switch t {
+	case FUNC_OBJ, BUILTIN_OBJ:
+		return "function"
+	case ANY:
+		return "any value"
+	default:
+		return strings.ToLower(string(t))
+	}
+}
@@ -0,0 +1,152 @@
+package object
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ToObject converts a Go value to a Milo object. Numbers of any Go numeric
+// type become numbers, slices and arrays become arrays, maps with string,
+// numeric or boolean keys become maps with their keys in sorted order, nil
+// becomes null and objects are returned unchanged.
+func ToObject(value any) (Object, error) {
+	if value == nil {
+		return NULL, nil
+	}
+
+	if obj, ok := value.(Object); ok {
+		return obj, nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Bool:
+		return NewBoolean(v.Bool()), nil
+	case reflect.String:
+		return NewString(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewNumber(float64(v.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return NewNumber(float64(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return NewNumber(v.Float()), nil
+	case reflect.Slice, reflect.Array:
+		elements := []Object{}
+		for i := 0; i < v.Len(); i++ {
+			el, err := ToObject(v.Index(i).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			elements = append(elements, el)
+		}
+		return NewArray(elements), nil
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return lessKey(keys[i], keys[j])
+		})
+
+		m := NewMap()
+		for _, key := range keys {
+			k, err := ToObject(key.Interface())
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := ToObject(v.MapIndex(key).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("key %v: %w", key, err)
+			}
+
+			if err := m.Set(k, value); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %T to a milo value", value)
+	}
+}
+
+// lessKey orders map keys of the same kind, falling back to their text.
+func lessKey(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.String:
+		return a.String() < b.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	default:
+		return fmt.Sprint(a) < fmt.Sprint(b)
+	}
+}
+
+// FromObject converts a Milo object to a Go value: numbers become float64,
+// strings string, booleans bool, null nil, arrays []any, and maps
+// map[string]any, or map[any]any if some keys are not strings. Arrays and
+// maps that contain themselves are converted to Go values that do too.
+// Other objects are returned unchanged.
+func FromObject(obj Object) any {
+	return fromObject(obj, make(map[Object]any))
+}
+
+// fromObject converts obj, reusing the conversions in seen so that shared
+// and cyclic structures keep their shape.
+func fromObject(obj Object, seen map[Object]any) any {
+	if obj == nil {
+		return nil
+	}
+
+	if value, ok := seen[obj]; ok {
+		return value
+	}
+
+	switch obj := obj.(type) {
+	case *Number, *String, *Boolean, *Null:
+		return obj.Value()
+	case *Array:
+		// allocated before converting the elements, which may refer to it
+		elements := make([]any, obj.Len())
+		seen[obj] = elements
+		for i, el := range obj.Elements() {
+			elements[i] = fromObject(el, seen)
+		}
+		return elements
+	case *Map:
+		keys := obj.Keys()
+		if allStrings(keys) {
+			entries := make(map[string]any, len(keys))
+			seen[obj] = entries
+			for _, key := range keys {
+				value, _ := obj.Get(key)
+				entries[key.Value().(string)] = fromObject(value, seen)
+			}
+			return entries
+		}
+
+		entries := make(map[any]any, len(keys))
+		seen[obj] = entries
+		for _, key := range keys {
+			value, _ := obj.Get(key)
+			entries[key.Value()] = fromObject(value, seen)
+		}
+		return entries
+	default:
+		return obj
+	}
+}
+
+func allStrings(objs []Object) bool {
+	for _, obj := range objs {
+		if obj.Type() != STRING_OBJ {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package object
+
+import "strings"
+
+// Enum is a tagged union declared with enum. Its values are EnumValues of
+// one of its variants.
+type Enum struct {
+	name     string
+	variants []*Variant
+}
+
+func NewEnum(name string) *Enum {
+	return &Enum{name: name}
+}
+
+func (e *Enum) ToString() string { return "enum " + e.name }
+func (e *Enum) Type() ObjectType { return ENUM_OBJ }
+func (e *Enum) Value() any       { return e.variants }
+
+func (e *Enum) Name() string { return e.name }
+
+// AddVariant adds a variant with the given fields. A unit variant has no
+// fields and is a value rather than a constructor.
+func (e *Enum) AddVariant(name string, fields []string, unit bool) *Variant {
+	v := &Variant{enum: e, name: name, fields: fields}
+	if unit {
+		v.unit = &EnumValue{variant: v}
+	}
+	e.variants = append(e.variants, v)
+	return v
+}
+
+// Variant returns the variant called name.
+func (e *Enum) Variant(name string) (*Variant, bool) {
+	for _, v := range e.variants {
+		if v.name == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Variant is one variant of an Enum. Unless it is a unit variant, calling
+// it constructs an EnumValue from values for its fields.
+type Variant struct {
+	enum   *Enum
+	name   string
+	fields []string
+	unit   *EnumValue
+}
+
+func (v *Variant) ToString() string { return "variant " + v.Name() }
+func (v *Variant) Type() ObjectType { return VARIANT_OBJ }
+func (v *Variant) Value() any       { return v.fields }
+
+// Name is the name of the variant qualified by its enum, as in Shape.Circle.
+func (v *Variant) Name() string     { return v.enum.name + "." + v.name }
+func (v *Variant) Enum() *Enum      { return v.enum }
+func (v *Variant) Fields() []string { return v.fields }
+
+// FieldIndex returns the position of the field called name, or -1.
+func (v *Variant) FieldIndex(name string) int {
+	for i, field := range v.fields {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Unit returns the only value of a unit variant.
+func (v *Variant) Unit() (*EnumValue, bool) { return v.unit, v.unit != nil }
+
+// EnumValue is a value of an enum: a variant and values for its fields.
+type EnumValue struct {
+	variant *Variant
+	values  []Object
+}
+
+func NewEnumValue(variant *Variant, values []Object) *EnumValue {
+	return &EnumValue{variant: variant, values: values}
+}
+
+func (ev *EnumValue) ToString() string {
+	if ev.variant.unit != nil {
+		return ev.variant.Name()
+	}
+
+	values := make([]string, len(ev.values))
+	for i, value := range ev.values {
+		values[i] = Repr(value)
+	}
+	return ev.variant.Name() + "(" + strings.Join(values, ", ") + ")"
+}
+
+func (ev *EnumValue) Type() ObjectType { return ENUM_VALUE_OBJ }
+func (ev *EnumValue) Value() any       { return ev.values }
+
+func (ev *EnumValue) Variant() *Variant { return ev.variant }
+
+// Values returns the values of the fields, in the order the variant
+// declares them.
+func (ev *EnumValue) Values() []Object { return ev.values }
+
+// Get returns the value of the field called name.
+func (ev *EnumValue) Get(name string) (Object, bool) {
+	i := ev.variant.FieldIndex(name)
+	if i < 0 {
+		return nil, false
+	}
+	return ev.values[i], true
+}
@@ -0,0 +1,76 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is a failure as a value. Functions such as fs.readFile return
+// errors for programs to inspect, and throw raises them. Runtime errors
+// become errors of kind RuntimeError when they are caught.
+//
+// An Error is also a Go error, which is how a raised error unwinds through
+// the evaluator. Its Error method returns the message, or the text of the
+// Go error a runtime error was made from.
+type Error struct {
+	message string
+	kind    string
+
+	// line and stack are set when the error is raised. stack lists the
+	// functions that were running, innermost first, as "name (line n)",
+	// noting any callers left out because they made a call in tail position.
+	line  int
+	stack []string
+
+	// cause is the Go error a runtime error was made from.
+	cause error
+}
+
+func NewError(kind, message string) *Error {
+	return &Error{kind: kind, message: message}
+}
+
+// NewRuntimeError returns an error of kind RuntimeError made from err,
+// described by message.
+func NewRuntimeError(message string, err error) *Error {
+	return &Error{kind: "RuntimeError", message: message, cause: err}
+}
+
+func (e *Error) ToString() string { return "error: " + e.message }
+func (e *Error) Type() ObjectType { return ERROR_OBJ }
+func (e *Error) Value() any       { return e.message }
+
+func (e *Error) Message() string { return e.message }
+func (e *Error) Kind() string    { return e.kind }
+func (e *Error) Line() int       { return e.line }
+func (e *Error) Stack() []string { return e.stack }
+
+// Raised reports whether the error has been raised and knows where.
+func (e *Error) Raised() bool { return e.stack != nil }
+
+// At returns a copy of the error recording that it was raised at line,
+// with stack listing the running functions.
+func (e *Error) At(line int, stack []string) *Error {
+	raised := *e
+	raised.line, raised.stack = line, stack
+	return &raised
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.cause.Error()
+	}
+	return e.message
+}
+func (e *Error) Unwrap() error { return e.cause }
+
+// Trace renders the error with its kind followed by its stack, one
+// function per line.
+func (e *Error) Trace() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s: %s", e.kind, e.message)
+	for _, frame := range e.stack {
+		out.WriteString("\n    at " + frame)
+	}
+	return out.String()
+}
@@ -1,22 +1,65 @@
 package object
 
-import "github.com/slinky55/milo/ast"
+import (
+	"github.com/slinky55/milo/ast"
+	"strings"
+)
 
 type Function struct {
+	name   string
 	stmts  []ast.Statement
-	params []string
+	params []ast.Pattern
+	env    *Environment
 }
 
-func NewFunction(stmts []ast.Statement, params []*ast.IdentExpr) *Function {
-	fn := &Function{
-		stmts: stmts,
+// NewFunction creates a function. name may be empty for anonymous functions.
+func NewFunction(name string, stmts []ast.Statement, params []ast.Pattern, env *Environment) *Function {
+	return &Function{
+		name:   name,
+		stmts:  stmts,
+		params: params,
+		env:    env,
 	}
-	for _, param := range params {
-		fn.params = append(fn.params, param.Value)
-	}
-	return fn
 }
 
 func (f *Function) ToString() string { return "function" }
 func (f *Function) Type() ObjectType { return FUNC_OBJ }
 func (f *Function) Value() any       { return f.stmts }
+
+func (f *Function) Name() string          { return f.name }
+func (f *Function) Params() []ast.Pattern { return f.params }
+func (f *Function) Body() []ast.Statement { return f.stmts }
+
+// Arity is the number of arguments the function accepts. Parameters with
+// default values may be omitted and a rest parameter takes any number.
+func (f *Function) Arity() Arity {
+	required := 0
+	for _, param := range f.params {
+		switch param.(type) {
+		case *ast.RestPattern:
+			return Variadic(required)
+		case *ast.DefaultPattern:
+		default:
+			required++
+		}
+	}
+	return Optional(required, len(f.params))
+}
+
+// Signature renders the function's name and parameter list, as in
+// add(x, y = 1).
+func (f *Function) Signature() string {
+	var params []string
+	for _, param := range f.params {
+		params = append(params, param.ToString())
+	}
+
+	name := f.name
+	if name == "" {
+		name = "fn"
+	}
+	return name + "(" + strings.Join(params, ", ") + ")"
+}
+
+// Env is the environment the function was defined in.
+func (f *Function) Env() *Environment { return f.env }
@@ -0,0 +1,84 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HashKey identifies a map key by type and value.
+type HashKey struct {
+	Type  ObjectType
+	Value any
+}
+
+// HashKeyOf returns the key for obj. Only numbers, strings and booleans
+// can be used as map keys.
+func HashKeyOf(obj Object) (HashKey, bool) {
+	switch obj.Type() {
+	case NUMBER_OBJ, STRING_OBJ, BOOLEAN_OBJ:
+		return HashKey{Type: obj.Type(), Value: obj.Value()}, true
+	default:
+		return HashKey{}, false
+	}
+}
+
+type mapEntry struct {
+	key   Object
+	value Object
+}
+
+// Map is a hash map that remembers the order keys were first inserted in.
+type Map struct {
+	order   []HashKey
+	entries map[HashKey]mapEntry
+}
+
+func NewMap() *Map {
+	return &Map{entries: make(map[HashKey]mapEntry)}
+}
+
+func (m *Map) ToString() string {
+	var pairs []string
+	for _, hk := range m.order {
+		entry := m.entries[hk]
+		pairs = append(pairs, Repr(entry.key)+": "+Repr(entry.value))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func (m *Map) Type() ObjectType { return MAP_OBJ }
+func (m *Map) Value() any       { return m.entries }
+
+func (m *Map) Len() int { return len(m.order) }
+
+// Get returns the value stored under key.
+func (m *Map) Get(key Object) (Object, bool) {
+	hk, ok := HashKeyOf(key)
+	if !ok {
+		return nil, false
+	}
+	entry, ok := m.entries[hk]
+	return entry.value, ok
+}
+
+func (m *Map) Set(key Object, value Object) error {
+	hk, ok := HashKeyOf(key)
+	if !ok {
+		return fmt.Errorf("%s cannot be used as a map key", TypeName(key.Type()))
+	}
+
+	if _, exists := m.entries[hk]; !exists {
+		m.order = append(m.order, hk)
+	}
+	m.entries[hk] = mapEntry{key: key, value: value}
+	return nil
+}
+
+// Keys returns the keys in insertion order.
+func (m *Map) Keys() []Object {
+	var keys []Object
+	for _, hk := range m.order {
+		keys = append(keys, m.entries[hk].key)
+	}
+	return keys
+}
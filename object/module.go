@@ -0,0 +1,40 @@
+package object
+
+import "sort"
+
+// Module is the namespace of an imported module. It holds the variables
+// the module exported.
+type Module struct {
+	name    string
+	exports map[string]Object
+}
+
+func NewModule(name string) *Module {
+	return &Module{name: name, exports: make(map[string]Object)}
+}
+
+func (m *Module) ToString() string { return "module " + m.name }
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Value() any       { return m.exports }
+
+func (m *Module) Name() string { return m.name }
+
+// Get returns the export called name.
+func (m *Module) Get(name string) (Object, bool) {
+	obj, ok := m.exports[name]
+	return obj, ok
+}
+
+func (m *Module) Export(name string, value Object) {
+	m.exports[name] = value
+}
+
+// Names returns the names of the exports in sorted order.
+func (m *Module) Names() []string {
+	var names []string
+	for name := range m.exports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,13 +1,27 @@
 package object
 
+import "strconv"
+
 type ObjectType string
 
 const (
-	NUMBER_OBJ  = "NUMBER"
-	STRING_OBJ  = "STRING"
-	BOOLEAN_OBJ = "BOOLEAN"
-	FUNC_OBJ    = "FUNC"
-	NULL_OBJ    = "NULL"
+	NUMBER_OBJ     = "NUMBER"
+	STRING_OBJ     = "STRING"
+	BOOLEAN_OBJ    = "BOOLEAN"
+	FUNC_OBJ       = "FUNC"
+	BUILTIN_OBJ    = "BUILTIN"
+	NULL_OBJ       = "NULL"
+	ARRAY_OBJ      = "ARRAY"
+	MAP_OBJ        = "MAP"
+	MODULE_OBJ     = "MODULE"
+	ERROR_OBJ      = "ERROR"
+	RESULT_OBJ     = "RESULT"
+	STRUCT_OBJ     = "STRUCT"
+	INSTANCE_OBJ   = "INSTANCE"
+	ENUM_OBJ       = "ENUM"
+	VARIANT_OBJ    = "VARIANT"
+	ENUM_VALUE_OBJ = "ENUM VALUE"
+	RETURN_OBJ     = "RETURN"
 )
 
 type Object interface {
@@ -15,3 +29,15 @@ type Object interface {
 	ToString() string
 	Value() any
 }
+
+// Repr renders obj the way it appears inside arrays and maps, with strings
+// quoted.
+func Repr(obj Object) string {
+	if obj == nil {
+		return NULL.ToString()
+	}
+	if obj.Type() == STRING_OBJ {
+		return strconv.Quote(obj.ToString())
+	}
+	return obj.ToString()
+}
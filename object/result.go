@@ -0,0 +1,26 @@
+package object
+
+// Result is the outcome of an operation that may fail: Ok holding its
+// value, or Err holding the reason it failed, usually an Error.
+type Result struct {
+	ok    bool
+	value Object
+}
+
+func NewOk(value Object) *Result  { return &Result{ok: true, value: value} }
+func NewErr(value Object) *Result { return &Result{ok: false, value: value} }
+
+func (r *Result) ToString() string {
+	if r.ok {
+		return "Ok(" + Repr(r.value) + ")"
+	}
+	return "Err(" + Repr(r.value) + ")"
+}
+
+func (r *Result) Type() ObjectType { return RESULT_OBJ }
+func (r *Result) Value() any       { return r.value }
+
+func (r *Result) IsOk() bool { return r.ok }
+
+// Unwrap returns the value held, whether Ok or Err.
+func (r *Result) Unwrap() Object { return r.value }
@@ -0,0 +1,15 @@
+package object
+
+// ReturnValue wraps the value of a return statement while it unwinds to
+// the enclosing function call.
+type ReturnValue struct {
+	value Object
+}
+
+func NewReturnValue(val Object) *ReturnValue { return &ReturnValue{value: val} }
+
+func (rv *ReturnValue) ToString() string { return rv.value.ToString() }
+func (rv *ReturnValue) Type() ObjectType { return RETURN_OBJ }
+func (rv *ReturnValue) Value() any       { return rv.value }
+
+func (rv *ReturnValue) Unwrap() Object { return rv.value }
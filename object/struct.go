@@ -0,0 +1,96 @@
+package object
+
+import "strings"
+
+// Struct is a record type declared with struct. Calling it creates an
+// Instance holding a value for each of its fields.
+type Struct struct {
+	name    string
+	fields  []string
+	methods map[string]*Function
+}
+
+func NewStruct(name string, fields []string) *Struct {
+	return &Struct{name: name, fields: fields, methods: map[string]*Function{}}
+}
+
+func (s *Struct) ToString() string { return "struct " + s.name }
+func (s *Struct) Type() ObjectType { return STRUCT_OBJ }
+func (s *Struct) Value() any       { return s.fields }
+
+func (s *Struct) Name() string     { return s.name }
+func (s *Struct) Fields() []string { return s.fields }
+
+// FieldIndex returns the position of the field called name, or -1.
+func (s *Struct) FieldIndex(name string) int {
+	for i, field := range s.fields {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Method returns the method called name, defined in an impl block.
+func (s *Struct) Method(name string) (*Function, bool) {
+	fn, ok := s.methods[name]
+	return fn, ok
+}
+
+// SetMethod defines the method called name, replacing any defined before.
+func (s *Struct) SetMethod(name string, fn *Function) { s.methods[name] = fn }
+
+// Instance is a value of a struct type, with one value per field in the
+// order the struct declares them.
+type Instance struct {
+	def    *Struct
+	values []Object
+
+	// printing is set while ToString renders the instance, so that an
+	// instance reachable from its own fields renders as ... there.
+	printing bool
+}
+
+func NewInstance(def *Struct, values []Object) *Instance {
+	return &Instance{def: def, values: values}
+}
+
+func (i *Instance) ToString() string {
+	if i.printing {
+		return "..."
+	}
+	i.printing = true
+	defer func() { i.printing = false }()
+
+	fields := make([]string, len(i.values))
+	for n, value := range i.values {
+		fields[n] = i.def.fields[n] + ": " + Repr(value)
+	}
+	return i.def.name + "(" + strings.Join(fields, ", ") + ")"
+}
+
+func (i *Instance) Type() ObjectType { return INSTANCE_OBJ }
+func (i *Instance) Value() any       { return i.values }
+
+// Struct returns the struct i is an instance of.
+func (i *Instance) Struct() *Struct { return i.def }
+
+// Get returns the value of the field called name.
+func (i *Instance) Get(name string) (Object, bool) {
+	n := i.def.FieldIndex(name)
+	if n < 0 {
+		return nil, false
+	}
+	return i.values[n], true
+}
+
+// Set replaces the value of the field called name. It reports false if
+// the struct has no such field.
+func (i *Instance) Set(name string, value Object) bool {
+	n := i.def.FieldIndex(name)
+	if n < 0 {
+		return false
+	}
+	i.values[n] = value
+	return true
+}
@@ -0,0 +1,315 @@
+package optimizer
+
+import (
+	"github.com/slinky55/milo/ast"
+	"github.com/slinky55/milo/evaluator"
+	"github.com/slinky55/milo/object"
+	"github.com/slinky55/milo/token"
+)
+
+// Optimizer rewrites a parsed program before evaluation. It folds
+// constant expressions and removes code that can never run.
+type Optimizer struct{}
+
+func New() *Optimizer {
+	return &Optimizer{}
+}
+
+func (o *Optimizer) Optimize(program *ast.Program) *ast.Program {
+	program.Statements = o.optimizeStatements(program.Statements)
+	return program
+}
+
+func (o *Optimizer) optimizeStatements(stmts []ast.Statement) []ast.Statement {
+	var out []ast.Statement
+
+	for i, stmt := range stmts {
+		stmt = o.optimizeStatement(stmt)
+
+		// a dead if yields null, which is the value of a block ending in
+		// it, so only the ones before the last statement can be dropped
+		if es, ok := stmt.(*ast.ExpressionStatement); ok && isDeadIf(es.Expr) && i < len(stmts)-1 {
+			continue
+		}
+
+		out = append(out, stmt)
+
+		// nothing after a return or throw can run
+		switch stmt.(type) {
+		case *ast.ReturnStatement, *ast.ThrowStatement:
+			return out
+		}
+	}
+
+	return out
+}
+
+func (o *Optimizer) optimizeStatement(node ast.Statement) ast.Statement {
+	switch stmt := node.(type) {
+	case *ast.LetStatement:
+		o.optimizePattern(stmt.Pattern)
+		stmt.Expr = o.optimizeExpr(stmt.Expr)
+	case *ast.ExportStatement:
+		o.optimizeStatement(stmt.Let)
+	case *ast.ReturnStatement:
+		stmt.Expr = o.optimizeExpr(stmt.Expr)
+	case *ast.ThrowStatement:
+		stmt.Expr = o.optimizeExpr(stmt.Expr)
+	case *ast.ImplStatement:
+		for _, method := range stmt.Methods {
+			o.optimizeExpr(method)
+		}
+	case *ast.AssignStatement:
+		stmt.Target.Object = o.optimizeExpr(stmt.Target.Object)
+		stmt.Value = o.optimizeExpr(stmt.Value)
+	case *ast.ExpressionStatement:
+		stmt.Expr = o.optimizeExpr(stmt.Expr)
+	}
+
+	return node
+}
+
+func (o *Optimizer) optimizeBlock(block *ast.StatementBlock) *ast.StatementBlock {
+	if block != nil {
+		block.Statements = o.optimizeStatements(block.Statements)
+	}
+	return block
+}
+
+func (o *Optimizer) optimizeExpr(node ast.Expression) ast.Expression {
+	switch expr := node.(type) {
+	case *ast.PrefixExpression:
+		expr.Right = o.optimizeExpr(expr.Right)
+		return o.foldPrefix(expr)
+	case *ast.BinaryExpression:
+		expr.Left = o.optimizeExpr(expr.Left)
+		expr.Right = o.optimizeExpr(expr.Right)
+		return o.foldBinary(expr)
+	case *ast.IfExpr:
+		return o.optimizeIf(expr)
+	case *ast.TernaryExpr:
+		expr.Condition = o.optimizeExpr(expr.Condition)
+		expr.Consequence = o.optimizeExpr(expr.Consequence)
+		expr.Alternative = o.optimizeExpr(expr.Alternative)
+		if cond, ok := expr.Condition.(*ast.BooleanExpr); ok {
+			if cond.Value {
+				return expr.Consequence
+			}
+			return expr.Alternative
+		}
+	case *ast.MatchExpr:
+		expr.Subject = o.optimizeExpr(expr.Subject)
+		for _, arm := range expr.Arms {
+			o.optimizePattern(arm.Pattern)
+			if arm.Expr != nil {
+				arm.Expr = o.optimizeExpr(arm.Expr)
+			}
+			o.optimizeBlock(arm.Block)
+		}
+	case *ast.TryExpr:
+		o.optimizeBlock(expr.Body)
+		o.optimizeBlock(expr.Catch)
+		o.optimizeBlock(expr.Finally)
+	case *ast.FunctionExpr:
+		for _, param := range expr.Parameters {
+			o.optimizePattern(param)
+		}
+		o.optimizeBlock(expr.Body)
+	case *ast.CallExpr:
+		expr.Function = o.optimizeExpr(expr.Function)
+		for i, arg := range expr.Arguments {
+			expr.Arguments[i] = o.optimizeExpr(arg)
+		}
+		for _, arg := range expr.Named {
+			arg.Value = o.optimizeExpr(arg.Value)
+		}
+	case *ast.PipeExpr:
+		expr.Left = o.optimizeExpr(expr.Left)
+		expr.Right = o.optimizeExpr(expr.Right)
+	case *ast.PropagateExpr:
+		expr.Expr = o.optimizeExpr(expr.Expr)
+	case *ast.ArrayExpr:
+		for i, el := range expr.Elements {
+			expr.Elements[i] = o.optimizeExpr(el)
+		}
+	case *ast.MapExpr:
+		for i := range expr.Keys {
+			expr.Keys[i] = o.optimizeExpr(expr.Keys[i])
+			expr.Values[i] = o.optimizeExpr(expr.Values[i])
+		}
+	case *ast.IndexExpr:
+		expr.Left = o.optimizeExpr(expr.Left)
+		expr.Index = o.optimizeExpr(expr.Index)
+	case *ast.MemberExpr:
+		expr.Object = o.optimizeExpr(expr.Object)
+	}
+
+	return node
+}
+
+func (o *Optimizer) optimizePattern(node ast.Pattern) {
+	switch pattern := node.(type) {
+	case *ast.LiteralPattern:
+		pattern.Value = o.optimizeExpr(pattern.Value)
+	case *ast.RangePattern:
+		pattern.Low = o.optimizeExpr(pattern.Low)
+		pattern.High = o.optimizeExpr(pattern.High)
+	case *ast.OrPattern:
+		for _, alt := range pattern.Alternatives {
+			o.optimizePattern(alt)
+		}
+	case *ast.ArrayPattern:
+		for _, el := range pattern.Elements {
+			o.optimizePattern(el)
+		}
+	case *ast.MapPattern:
+		for _, value := range pattern.Values {
+			o.optimizePattern(value)
+		}
+	case *ast.DefaultPattern:
+		o.optimizePattern(pattern.Target)
+		pattern.Default = o.optimizeExpr(pattern.Default)
+	case *ast.VariantPattern:
+		for _, arg := range pattern.Args {
+			o.optimizePattern(arg)
+		}
+	}
+}
+
+func (o *Optimizer) foldPrefix(expr *ast.PrefixExpression) ast.Expression {
+	// ++ and -- mutate their operand, so only pure operators are folded
+	if expr.Operator != "!" && expr.Operator != "-" {
+		return expr
+	}
+
+	right, ok := constant(expr.Right)
+	if !ok {
+		return expr
+	}
+
+	// an expression that fails is left to fail when it runs, where the
+	// error can be caught
+	value, err := evaluator.PrefixOp(expr.Operator, right)
+	if err != nil {
+		return expr
+	}
+
+	return literal(value, expr)
+}
+
+func (o *Optimizer) foldBinary(expr *ast.BinaryExpression) ast.Expression {
+	left, ok := constant(expr.Left)
+	if !ok {
+		return expr
+	}
+
+	// the right side of ?? only matters when the left one is null
+	if expr.Operator == "??" {
+		if left == object.NULL {
+			return expr.Right
+		}
+		return expr.Left
+	}
+
+	right, ok := constant(expr.Right)
+	if !ok {
+		return expr
+	}
+
+	value, err := evaluator.BinaryOp(expr.Operator, left, right)
+	if err != nil {
+		return expr
+	}
+
+	return literal(value, expr)
+}
+
+func (o *Optimizer) optimizeIf(expr *ast.IfExpr) ast.Expression {
+	expr.Condition = o.optimizeExpr(expr.Condition)
+
+	// only the branch that can run is folded, the other is dropped
+	cond, ok := expr.Condition.(*ast.BooleanExpr)
+	if !ok {
+		o.optimizeBlock(expr.Consequence)
+		o.optimizeBlock(expr.Alternative)
+		return expr
+	}
+
+	if cond.Value {
+		o.optimizeBlock(expr.Consequence)
+		expr.Alternative = nil
+		return expr
+	}
+
+	o.optimizeBlock(expr.Alternative)
+
+	if expr.Alternative == nil {
+		expr.Consequence = &ast.StatementBlock{Token: expr.Consequence.Token}
+		return expr
+	}
+
+	// the alternative is the only reachable branch, keep it behind a
+	// constant true condition so the expression still yields its value
+	return &ast.IfExpr{
+		Token:       expr.Token,
+		Condition:   &ast.BooleanExpr{Token: token.New(token.TRUE, "true"), Value: true},
+		Consequence: expr.Alternative,
+	}
+}
+
+// isDeadIf reports whether expr is an if that can never run either branch.
+func isDeadIf(expr ast.Expression) bool {
+	ie, ok := expr.(*ast.IfExpr)
+	if !ok || ie.Alternative != nil {
+		return false
+	}
+
+	cond, ok := ie.Condition.(*ast.BooleanExpr)
+	return ok && !cond.Value
+}
+
+// constant returns the value of a literal expression.
+func constant(expr ast.Expression) (object.Object, bool) {
+	switch lit := expr.(type) {
+	case *ast.NumberExpr:
+		return object.NewNumber(lit.Value), true
+	case *ast.StringExpr:
+		return object.NewString(lit.Value), true
+	case *ast.BooleanExpr:
+		return object.NewBoolean(lit.Value), true
+	case *ast.NullExpr:
+		return object.NULL, true
+	default:
+		return nil, false
+	}
+}
+
+// literal converts a folded value back into an expression, falling back
+// to the original expression for values that have no literal form.
+func literal(value object.Object, orig ast.Expression) ast.Expression {
+	switch value.Type() {
+	case object.NUMBER_OBJ:
+		return &ast.NumberExpr{
+			Token: token.New(token.NUMBER, value.ToString()),
+			Value: value.Value().(float64),
+		}
+	case object.STRING_OBJ:
+		return &ast.StringExpr{
+			Token: token.New(token.STRING, value.ToString()),
+			Value: value.Value().(string),
+		}
+	case object.BOOLEAN_OBJ:
+		t := token.Type(token.FALSE)
+		if value.Value().(bool) {
+			t = token.TRUE
+		}
+		return &ast.BooleanExpr{
+			Token: token.New(t, value.ToString()),
+			Value: value.Value().(bool),
+		}
+	case object.NULL_OBJ:
+		return &ast.NullExpr{Token: token.New(token.NULL, "null")}
+	default:
+		return orig
+	}
+}
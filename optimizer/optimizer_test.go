@@ -0,0 +1,167 @@
+package optimizer
+
+import (
+	"context"
+	"github.com/slinky55/milo/evaluator"
+	"github.com/slinky55/milo/lexer"
+	"github.com/slinky55/milo/parser"
+	"strings"
+	"testing"
+)
+
+func optimize(t *testing.T, input string) string {
+	l := lexer.New(input)
+	p := parser.New(l)
+
+	program := p.Parse()
+	if len(p.Errors) > 0 {
+		t.Fatalf("parser had errors: %v", p.Errors)
+	}
+
+	program = New().Optimize(program)
+
+	var stmts []string
+	for _, stmt := range program.Statements {
+		stmts = append(stmts, stmt.ToString())
+	}
+
+	return strings.Join(stmts, " ")
+}
+
+func TestConstantFolding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 * 60 * 60", "7200"},
+		{"let x = (5 + 3) * 2;", "let x = 16;"},
+		{"10 / 4", "2.5"},
+		{"-5 + 2", "-3"},
+		{"!true", "false"},
+		{"3 > 2", "true"},
+		{"1 == 2", "false"},
+		{"\"foo\" != \"bar\"", "true"},
+		{"\"foo\" + \"bar\"", "foobar"},
+		{"x * (2 + 3)", "(x * 5)"},
+		{"x + 2 + 3", "((x + 2) + 3)"},
+		{"add(1 + 1, 2 * 3)", "add(2, 6)"},
+		{"add(1 + 1, y: 2 * 3)", "add(2, y: 6)"},
+		{"++5", "(++5)"},
+		{"null == null", "true"},
+		{"null ?? 2 * 3", "6"},
+		{"null ?? x", "x"},
+		{"1 ?? x", "1"},
+		{"x ?? 1 + 1", "(x ?? 2)"},
+		{"[1 + 1, {a: 2 * 2}][0 + 1]", "[2, {a: 4}][1]"},
+		{"1 < 2 ? x : y", "x"},
+		{"let [a = 1 + 1, {b = 2 * 2}] = x;", "let [a = 2, {b = 4}] = x;"},
+		{"fn (x = 2 * 3) { x }", "fn (x = 6) { x }"},
+		{"false ? x : 1 + 1", "2"},
+		{"c ? 1 + 1 : y", "(c ? 2 : y)"},
+		{"match (1 + 1) { -2 => x, _ => 3 - 1 }", "match (2) { -2 => x, _ => 2 }"},
+	}
+
+	for _, test := range tests {
+		actual := optimize(t, test.input)
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestDeadCodeElimination(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if (false) { x }", "if (false) {  }"},
+		{"if (1 > 2) { x } y", "y"},
+		{"if (true) { 1 } else { 1 / 0 }", "if (true) { 1 }"},
+		{"if (false) { 1 / 0 } else { 2 }", "if (true) { 2 }"},
+		{"if (true) { x } else { y }", "if (true) { x }"},
+		{"if (false) { x } else { y }", "if (true) { y }"},
+		{"let a = if (2 < 1) { x } else { y };", "let a = if (true) { y };"},
+		{"if (z) { x } else { y }", "if (z) { x } else { y }"},
+		{"fn (x) { return x; let y = 2; }", "fn (x) { return x; }"},
+		{"fn (x) { if (false) { x } return 1 + 1; y }", "fn (x) { return 2; }"},
+		{"fn (x) { throw \"a\" + \"b\"; x }", "fn (x) { throw ab; }"},
+		{"try { 1 + 1 } catch (e) { if (false) { x } } finally { 2 * 3 }", "try { 2 } catch (e) { if (false) {  } } finally { 6 }"},
+		{"fn () { if (false) { x } 5 }", "fn () { 5 }"},
+	}
+
+	for _, test := range tests {
+		actual := optimize(t, test.input)
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+// TestFoldingErrors checks that expressions that fail are left unfolded,
+// to fail when they run.
+func TestFoldingErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 / 0", "(1 / 0)"},
+		{"let x = 5 / (2 - 2);", "let x = (5 / 0);"},
+		{"-true", "(-true)"},
+		{"1 + true", "(1 + true)"},
+	}
+
+	for _, test := range tests {
+		actual := optimize(t, test.input)
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+// TestOptimizedResults checks that programs evaluate to the same value with
+// and without optimization.
+func TestOptimizedResults(t *testing.T) {
+	tests := []string{
+		"let f = fn() { 5; if (false) { 1 } }; f()",
+		"let f = fn() { if (false) { 1 } 5 }; f()",
+		"5; if (1 > 2) { 1 }",
+		"let f = fn() { if (true) { return 1; } else { 1 / 0 } }; f()",
+		"let f = fn(x) { if (false) { 1 } else { x * 2 } }; f(3)",
+		"let a = 2 * 60 * 60; a + 1",
+		"try { 1 } catch (e) { 2 } finally { if (false) { 3 } }",
+		"try { 1 / 0 } catch (e) { e.message }",
+	}
+
+	for _, test := range tests {
+		expected, err := run(test, false)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test, err)
+			continue
+		}
+
+		actual, err := run(test, true)
+		if err != nil {
+			t.Errorf("%s: unexpected error with optimization: %s", test, err)
+			continue
+		}
+
+		if actual != expected {
+			t.Errorf("%s: expected %s, found %s with optimization", test, expected, actual)
+		}
+	}
+}
+
+func run(input string, optimize bool) (string, error) {
+	p := parser.New(lexer.New(input))
+	program := p.Parse()
+
+	if optimize {
+		program = New().Optimize(program)
+	}
+
+	value, err := evaluator.New(nil).Run(context.Background(), program)
+	if err != nil {
+		return "", err
+	}
+	return value.ToString(), nil
+}
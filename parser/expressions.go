@@ -2,6 +2,7 @@ package parser
 
 import (
 	"github.com/slinky55/milo/ast"
+	"github.com/slinky55/milo/lexer"
 	"github.com/slinky55/milo/token"
 	"strconv"
 )
@@ -12,19 +13,37 @@ func (p *Parser) parseExpr(precedence int) ast.Expression {
 	case token.BANG, token.MINUS, token.INCREMENT, token.DECREMENT:
 		left = p.parsePrefixExpr()
 	case token.IDENT:
-		left = p.parseIdentExpr()
+		if p.peek.Type == token.ARROW {
+			left = p.parseArrowFunction()
+		} else {
+			left = p.parseIdentExpr()
+		}
 	case token.NUMBER:
 		left = p.parseNumberExpr()
 	case token.TRUE, token.FALSE:
 		left = p.parseBoolExpr()
 	case token.IF:
 		left = p.parseIfExpr()
+	case token.MATCH:
+		left = p.parseMatchExpr()
+	case token.TRY:
+		left = p.parseTryExpr()
 	case token.FUNCTION:
 		left = p.parseFunctionExpr()
 	case token.LPAREN:
-		left = p.parseGroupedExpression()
+		if p.arrowAhead() {
+			left = p.parseArrowFunction()
+		} else {
+			left = p.parseGroupedExpression()
+		}
 	case token.STRING:
 		left = p.parseStringExpr()
+	case token.NULL:
+		left = p.parseNullExpr()
+	case token.LBRACKET:
+		left = p.parseArrayExpr()
+	case token.LBRACE:
+		left = p.parseMapExpr()
 	default:
 		p.error("unexpected %s at start of expression", p.cur.Literal)
 		return nil
@@ -40,6 +59,18 @@ func (p *Parser) parseExpr(precedence int) ast.Expression {
 		switch p.cur.Type {
 		case token.LPAREN:
 			left = p.parseCallExpr(left)
+		case token.LBRACKET:
+			left = p.parseIndexExpr(left, false)
+		case token.DOT, token.QDOT:
+			left = p.parseMemberExpr(left)
+		case token.QUESTION:
+			if p.ternaryAhead(p.cur, p.peek.Type, *p.l) {
+				left = p.parseTernaryExpr(left)
+			} else {
+				left = p.parsePropagateExpr(left)
+			}
+		case token.PIPELINE:
+			left = p.parsePipeExpr(left)
 		default:
 			left = p.parseBinaryExpression(left)
 		}
@@ -157,6 +188,48 @@ func (p *Parser) parseIfExpr() *ast.IfExpr {
 	return expr
 }
 
+func (p *Parser) parseTryExpr() *ast.TryExpr {
+	expr := &ast.TryExpr{
+		Token: p.cur,
+	}
+
+	if !p.nextIfPeek(token.LBRACE) {
+		return nil
+	}
+
+	expr.Body = p.parseStmtBlock()
+
+	if p.peek.Type == token.CATCH {
+		p.next()
+
+		if !p.nextIfPeek(token.LPAREN) || !p.nextIfPeek(token.IDENT) {
+			return nil
+		}
+		expr.Param = p.parseIdentExpr()
+
+		if !p.nextIfPeek(token.RPAREN) || !p.nextIfPeek(token.LBRACE) {
+			return nil
+		}
+		expr.Catch = p.parseStmtBlock()
+	}
+
+	if p.peek.Type == token.FINALLY {
+		p.next()
+
+		if !p.nextIfPeek(token.LBRACE) {
+			return nil
+		}
+		expr.Finally = p.parseStmtBlock()
+	}
+
+	if expr.Catch == nil && expr.Finally == nil {
+		p.error("expected catch or finally after try block, but found %s", p.peek.Literal)
+		return nil
+	}
+
+	return expr
+}
+
 func (p *Parser) parseFunctionExpr() *ast.FunctionExpr {
 	expr := &ast.FunctionExpr{
 		Token: p.cur,
@@ -177,12 +250,118 @@ func (p *Parser) parseFunctionExpr() *ast.FunctionExpr {
 	return expr
 }
 
-func (p *Parser) parseParamList() []*ast.IdentExpr {
-	var params []*ast.IdentExpr
+// parseArrowFunction parses x => body or (params) => body. The body is a
+// block or a single expression, which becomes the function's value.
+func (p *Parser) parseArrowFunction() ast.Expression {
+	t := token.New(token.FUNCTION, "fn")
+	t.Line, t.Column = p.cur.Line, p.cur.Column
+
+	expr := &ast.FunctionExpr{Token: t}
+
+	if p.cur.Type == token.IDENT {
+		expr.Parameters = []ast.Pattern{p.parseIdentExpr()}
+	} else if expr.Parameters = p.parseParamList(); expr.Parameters == nil {
+		return nil
+	}
+
+	if !p.nextIfPeek(token.ARROW) {
+		return nil
+	}
 	p.next()
 
+	if p.cur.Type == token.LBRACE {
+		expr.Body = p.parseStmtBlock()
+		return expr
+	}
+
+	body := &ast.ExpressionStatement{Token: p.cur, Expr: p.parseExpr(LOWEST)}
+	if body.Expr == nil {
+		return nil
+	}
+
+	expr.Body = &ast.StatementBlock{Token: t, Statements: []ast.Statement{body}}
+	return expr
+}
+
+// arrowAhead reports whether the parenthesis at the current token starts
+// the parameter list of an arrow function, by scanning ahead to the
+// matching closing parenthesis without consuming any tokens. The answers
+// for the parentheses nested in it are remembered from the same scan, so
+// each token is scanned only once.
+func (p *Parser) arrowAhead() bool {
+	if arrow, ok := p.arrows[positionOf(p.cur)]; ok {
+		return arrow
+	}
+
+	scan := *p.l
+	open := []*token.Token{p.cur}
+
+	// closed is the opening parenthesis of the one closed by the token
+	// before t, which is an arrow function's if t is =>
+	var closed *token.Token
+
+	for t := p.peek; ; t = scan.NextToken() {
+		if closed != nil {
+			p.arrows[positionOf(closed)] = t.Type == token.ARROW
+			if len(open) == 0 {
+				return t.Type == token.ARROW
+			}
+			closed = nil
+		}
+
+		switch t.Type {
+		case token.EOF:
+			return false
+		case token.LPAREN:
+			open = append(open, t)
+		case token.RPAREN:
+			closed, open = open[len(open)-1], open[:len(open)-1]
+		}
+	}
+}
+
+// position locates a token in the source.
+type position struct {
+	line, column int
+}
+
+func positionOf(t *token.Token) position {
+	return position{line: t.Line, column: t.Column}
+}
+
+func (p *Parser) parseParamList() []ast.Pattern {
+	params := []ast.Pattern{}
+
+	if p.peek.Type == token.RPAREN {
+		p.next()
+		return params
+	}
+
 	for {
-		params = append(params, p.parseIdentExpr())
+		p.next()
+
+		if p.cur.Type == token.ELLIPSIS {
+			rest := &ast.RestPattern{Token: p.cur}
+			if !p.nextIfPeek(token.IDENT) {
+				return nil
+			}
+
+			rest.Ident = p.parseIdentExpr()
+			params = append(params, rest)
+
+			if p.peek.Type != token.RPAREN {
+				p.error("rest parameter %s must be last", rest.ToString())
+				return nil
+			}
+			p.next()
+			break
+		}
+
+		param := p.parseBindingElement()
+		if param == nil {
+			return nil
+		}
+		params = append(params, param)
 
 		if p.peek.Type == token.RPAREN {
 			p.next()
@@ -192,8 +371,6 @@ func (p *Parser) parseParamList() []*ast.IdentExpr {
 		if !p.nextIfPeek(token.COMMA) {
 			return nil
 		}
-
-		p.next()
 	}
 
 	return params
@@ -201,35 +378,594 @@ func (p *Parser) parseParamList() []*ast.IdentExpr {
 
 func (p *Parser) parseCallExpr(function ast.Expression) *ast.CallExpr {
 	call := &ast.CallExpr{
-		Token:    p.cur,
-		Function: function,
+		Token:     p.cur,
+		Function:  function,
+		Arguments: []ast.Expression{},
+	}
+
+	for p.peek.Type != token.RPAREN {
+		p.next()
+
+		if p.cur.Type == token.IDENT && p.peek.Type == token.COLON {
+			arg := &ast.NamedArg{Name: p.parseIdentExpr()}
+			p.next()
+			arg.Token = p.cur
+			p.next()
+
+			if arg.Value = p.parseExpr(LOWEST); arg.Value == nil {
+				return nil
+			}
+			call.Named = append(call.Named, arg)
+		} else {
+			if len(call.Named) > 0 {
+				p.error("positional argument after named argument in call to %s", function.ToString())
+				return nil
+			}
+
+			arg := p.parseExpr(LOWEST)
+			if arg == nil {
+				return nil
+			}
+			call.Arguments = append(call.Arguments, arg)
+		}
+
+		if p.peek.Type != token.RPAREN && !p.nextIfPeek(token.COMMA) {
+			return nil
+		}
 	}
 
-	call.Arguments = p.parseArgsList()
+	p.next()
 	return call
 }
 
-func (p *Parser) parseArgsList() []ast.Expression {
-	var args []ast.Expression
+// parseExprList parses comma separated expressions up to the end token.
+// It returns nil on error and an empty list if there are none.
+func (p *Parser) parseExprList(end token.Type) []ast.Expression {
+	list := []ast.Expression{}
 
-	if p.peek.Type == token.RPAREN {
+	if p.peek.Type == end {
 		p.next()
-		return args
+		return list
 	}
 
 	p.next()
 
-	args = append(args, p.parseExpr(LOWEST))
+	list = append(list, p.parseExpr(LOWEST))
 
 	for p.peek.Type == token.COMMA {
 		p.next()
 		p.next()
-		args = append(args, p.parseExpr(LOWEST))
+		list = append(list, p.parseExpr(LOWEST))
+	}
+
+	if !p.nextIfPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
+func (p *Parser) parseNullExpr() *ast.NullExpr {
+	return &ast.NullExpr{
+		Token: p.cur,
+	}
+}
+
+func (p *Parser) parseArrayExpr() ast.Expression {
+	expr := &ast.ArrayExpr{
+		Token: p.cur,
+	}
+
+	expr.Elements = p.parseExprList(token.RBRACKET)
+	if expr.Elements == nil {
+		return nil
+	}
+
+	return expr
+}
+
+func (p *Parser) parseMapExpr() ast.Expression {
+	expr := &ast.MapExpr{
+		Token: p.cur,
+	}
+
+	for p.peek.Type != token.RBRACE {
+		p.next()
+
+		var key ast.Expression
+		if p.cur.Type == token.IDENT && p.peek.Type == token.COLON {
+			// bare identifiers are shorthand for string keys
+			key = &ast.StringExpr{Token: p.cur, Value: p.cur.Literal}
+		} else if key = p.parseExpr(LOWEST); key == nil {
+			return nil
+		}
+
+		if !p.nextIfPeek(token.COLON) {
+			return nil
+		}
+		p.next()
+
+		value := p.parseExpr(LOWEST)
+		if value == nil {
+			return nil
+		}
+
+		expr.Keys = append(expr.Keys, key)
+		expr.Values = append(expr.Values, value)
+
+		if p.peek.Type != token.RBRACE && !p.nextIfPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.next()
+
+	return expr
+}
+
+func (p *Parser) parseIndexExpr(left ast.Expression, optional bool) ast.Expression {
+	expr := &ast.IndexExpr{
+		Token:    p.cur,
+		Left:     left,
+		Optional: optional,
+	}
+
+	p.next()
+
+	expr.Index = p.parseExpr(LOWEST)
+	if expr.Index == nil {
+		return nil
+	}
+
+	if !p.nextIfPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return expr
+}
+
+func (p *Parser) parseMemberExpr(object ast.Expression) ast.Expression {
+	t := p.cur
+	optional := t.Type == token.QDOT
+
+	// ?.[ is an optional index access
+	if optional && p.peek.Type == token.LBRACKET {
+		p.next()
+		return p.parseIndexExpr(object, true)
+	}
+
+	if !p.nextIfPeek(token.IDENT) {
+		return nil
+	}
+
+	return &ast.MemberExpr{
+		Token:    t,
+		Object:   object,
+		Property: p.parseIdentExpr(),
+		Optional: optional,
+	}
+}
+
+func (p *Parser) parsePipeExpr(left ast.Expression) ast.Expression {
+	expr := &ast.PipeExpr{
+		Token: p.cur,
+		Left:  left,
+	}
+
+	p.next()
+	expr.Right = p.parseExpr(PIPELINE)
+	if expr.Right == nil {
+		return nil
+	}
+
+	return expr
+}
+
+func (p *Parser) parseTernaryExpr(cond ast.Expression) ast.Expression {
+	expr := &ast.TernaryExpr{
+		Token:     p.cur,
+		Condition: cond,
+	}
+
+	p.next()
+
+	p.ternaries = append(p.ternaries, p.nesting)
+	expr.Consequence = p.parseExpr(LOWEST)
+	p.ternaries = p.ternaries[:len(p.ternaries)-1]
+
+	if expr.Consequence == nil {
+		return nil
+	}
+
+	if !p.nextIfPeek(token.COLON) {
+		return nil
+	}
+	p.next()
+
+	// parsing the alternative at the lowest precedence makes the
+	// operator right associative
+	expr.Alternative = p.parseExpr(LOWEST)
+	if expr.Alternative == nil {
+		return nil
+	}
+
+	return expr
+}
+
+func (p *Parser) parsePropagateExpr(left ast.Expression) ast.Expression {
+	return &ast.PropagateExpr{
+		Token: p.cur,
+		Expr:  left,
+	}
+}
+
+// ternaryAhead reports whether the ? token question, followed by a token of
+// type next and then the tokens of scan, starts a ternary rather than being
+// the postfix operator.
+// It is a ternary if an expression follows, except that a token which can
+// also continue the expression before the ?, like - or (, makes it one
+// only if a : is left for it at the same nesting once the other ternaries
+// there have been given theirs.
+func (p *Parser) ternaryAhead(question *token.Token, next token.Type, scan lexer.Lexer) bool {
+	if question == p.question {
+		return p.questionTernary
+	}
+
+	ternary := false
+	if startsExpr(next) {
+		ternary = !continuesExpr(next) || colonAhead(next, &scan, p.owedColons())
+	}
+
+	p.question, p.questionTernary = question, ternary
+	return ternary
+}
+
+// owedColons returns the number of ternaries at the current nesting that
+// are still to see their :.
+func (p *Parser) owedColons() int {
+	owed := 0
+	for _, nesting := range p.ternaries {
+		if nesting == p.nesting {
+			owed++
+		}
+	}
+	return owed
+}
+
+// colonAhead reports whether the expression made of t and the tokens of
+// scan has more than owed colons outside brackets, counting one more for
+// each ? in it that must start a ternary.
+func colonAhead(t token.Type, scan *lexer.Lexer, owed int) bool {
+	depth := 0
+	for {
+		switch t {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			if depth--; depth < 0 {
+				return false
+			}
+		case token.SEMICOLON, token.COMMA:
+			if depth == 0 {
+				return false
+			}
+		case token.EOF:
+			return false
+		case token.QUESTION:
+			next := scan.NextToken().Type
+			if depth == 0 && startsExpr(next) && !continuesExpr(next) {
+				owed++
+			}
+			t = next
+			continue
+		case token.COLON:
+			if depth == 0 {
+				if owed--; owed < 0 {
+					return true
+				}
+			}
+		}
+		t = scan.NextToken().Type
+	}
+}
+
+// continuesExpr reports whether a token of type t, besides beginning an
+// expression, can continue one as an operator.
+func continuesExpr(t token.Type) bool {
+	switch t {
+	case token.MINUS, token.LPAREN, token.LBRACKET:
+		return true
+	default:
+		return false
+	}
+}
+
+// startsExpr reports whether a token of type t can begin an expression.
+func startsExpr(t token.Type) bool {
+	switch t {
+	case token.BANG, token.MINUS, token.INCREMENT, token.DECREMENT,
+		token.IDENT, token.NUMBER, token.STRING, token.TRUE, token.FALSE, token.NULL,
+		token.IF, token.MATCH, token.TRY, token.FUNCTION,
+		token.LPAREN, token.LBRACKET, token.LBRACE:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Parser) parseMatchExpr() ast.Expression {
+	expr := &ast.MatchExpr{
+		Token: p.cur,
+	}
+
+	if !p.nextIfPeek(token.LPAREN) {
+		return nil
+	}
+	p.next()
+
+	expr.Subject = p.parseExpr(LOWEST)
+	if expr.Subject == nil {
+		return nil
 	}
 
 	if !p.nextIfPeek(token.RPAREN) {
 		return nil
 	}
 
-	return args
+	if !p.nextIfPeek(token.LBRACE) {
+		return nil
+	}
+
+	for p.peek.Type != token.RBRACE {
+		p.next()
+
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		expr.Arms = append(expr.Arms, arm)
+
+		if p.peek.Type != token.RBRACE && !p.nextIfPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.next()
+
+	return expr
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{
+		Token: p.cur,
+	}
+
+	arm.Pattern = p.parsePattern()
+	if arm.Pattern == nil {
+		return nil
+	}
+
+	if !p.nextIfPeek(token.ARROW) {
+		return nil
+	}
+	p.next()
+
+	if p.cur.Type == token.LBRACE {
+		arm.Block = p.parseStmtBlock()
+		return arm
+	}
+
+	arm.Expr = p.parseExpr(LOWEST)
+	if arm.Expr == nil {
+		return nil
+	}
+
+	return arm
+}
+
+func (p *Parser) parsePattern() ast.Pattern {
+	first := p.parsePrimaryPattern()
+	if first == nil || p.peek.Type != token.PIPE {
+		return first
+	}
+
+	pattern := &ast.OrPattern{
+		Token:        p.peek,
+		Alternatives: []ast.Pattern{first},
+	}
+
+	for p.peek.Type == token.PIPE {
+		p.next()
+		p.next()
+
+		alt := p.parsePrimaryPattern()
+		if alt == nil {
+			return nil
+		}
+		pattern.Alternatives = append(pattern.Alternatives, alt)
+	}
+
+	return pattern
+}
+
+func (p *Parser) parsePrimaryPattern() ast.Pattern {
+	switch p.cur.Type {
+	case token.IDENT:
+		if p.cur.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.cur}
+		}
+		if p.peek.Type == token.DOT {
+			return p.parseVariantPattern()
+		}
+	case token.NUMBER, token.STRING, token.TRUE, token.FALSE, token.NULL, token.MINUS:
+		t := p.cur
+
+		value := p.parseExpr(PREFIX)
+		if value == nil {
+			return nil
+		}
+
+		if p.peek.Type != token.DOTDOT {
+			return &ast.LiteralPattern{Token: t, Value: value}
+		}
+
+		p.next()
+		p.next()
+
+		high := p.parseExpr(PREFIX)
+		if high == nil {
+			return nil
+		}
+
+		return &ast.RangePattern{Token: t, Low: value, High: high}
+	}
+
+	p.error("unexpected %s in pattern", p.cur.Literal)
+	return nil
+}
+
+// parseBinding parses the target of a let binding: an identifier, an array
+// pattern or a map pattern.
+func (p *Parser) parseBinding() ast.Pattern {
+	switch p.cur.Type {
+	case token.IDENT:
+		return p.parseIdentExpr()
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseMapPattern()
+	}
+
+	p.error("unexpected %s in binding", p.cur.Literal)
+	return nil
+}
+
+// parseBindingElement parses a binding that may be followed by a default
+// value, as in array and map patterns and parameter lists.
+func (p *Parser) parseBindingElement() ast.Pattern {
+	target := p.parseBinding()
+	if target == nil || p.peek.Type != token.ASSIGN {
+		return target
+	}
+
+	p.next()
+	pattern := &ast.DefaultPattern{Token: p.cur, Target: target}
+	p.next()
+
+	pattern.Default = p.parseExpr(LOWEST)
+	if pattern.Default == nil {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.cur}
+
+	for p.peek.Type != token.RBRACKET {
+		p.next()
+
+		if p.cur.Type == token.ELLIPSIS {
+			rest := &ast.RestPattern{Token: p.cur}
+			if !p.nextIfPeek(token.IDENT) {
+				return nil
+			}
+
+			rest.Ident = p.parseIdentExpr()
+			pattern.Elements = append(pattern.Elements, rest)
+
+			if p.peek.Type != token.RBRACKET {
+				p.error("rest element must be last in %s", pattern.ToString())
+				return nil
+			}
+			break
+		}
+
+		el := p.parseBindingElement()
+		if el == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, el)
+
+		if p.peek.Type != token.RBRACKET && !p.nextIfPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.next()
+	return pattern
+}
+
+func (p *Parser) parseMapPattern() ast.Pattern {
+	pattern := &ast.MapPattern{Token: p.cur}
+
+	for p.peek.Type != token.RBRACE {
+		if !p.nextIfPeek(token.IDENT) {
+			return nil
+		}
+
+		key := p.parseIdentExpr()
+
+		var value ast.Pattern = key
+		if p.peek.Type == token.COLON {
+			p.next()
+			p.next()
+			value = p.parseBindingElement()
+		} else if p.peek.Type == token.ASSIGN {
+			value = p.parseBindingElement()
+		}
+
+		if value == nil {
+			return nil
+		}
+
+		pattern.Keys = append(pattern.Keys, key)
+		pattern.Values = append(pattern.Values, value)
+
+		if p.peek.Type != token.RBRACE && !p.nextIfPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.next()
+	return pattern
+}
+
+// parseVariantPattern parses Enum.Variant, optionally followed by patterns
+// for the variant's fields in parentheses. A plain name among them binds
+// the field.
+func (p *Parser) parseVariantPattern() ast.Pattern {
+	pattern := &ast.VariantPattern{Token: p.cur, Enum: p.parseIdentExpr()}
+	p.next()
+
+	if !p.nextIfPeek(token.IDENT) {
+		return nil
+	}
+	pattern.Variant = p.parseIdentExpr()
+
+	if p.peek.Type != token.LPAREN {
+		return pattern
+	}
+	p.next()
+
+	pattern.Args = []ast.Pattern{}
+	for p.peek.Type != token.RPAREN {
+		p.next()
+
+		var arg ast.Pattern
+		if p.cur.Type == token.IDENT && p.cur.Literal != "_" && p.peek.Type != token.DOT {
+			arg = p.parseIdentExpr()
+		} else if arg = p.parsePattern(); arg == nil {
+			return nil
+		}
+		pattern.Args = append(pattern.Args, arg)
+
+		if p.peek.Type != token.RPAREN && !p.nextIfPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.next()
+
+	return pattern
 }
@@ -11,6 +11,9 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	TERNARY
+	PIPELINE
+	COALESCE
 	EQUALITY
 	COMPARISON
 	SUM
@@ -29,6 +32,12 @@ var TokenPrecedence = map[token.Type]int{
 	token.MULTIPLY:  PRODUCT,
 	token.DIVIDE:    PRODUCT,
 	token.LPAREN:    CALL,
+	token.LBRACKET:  CALL,
+	token.DOT:       CALL,
+	token.QDOT:      CALL,
+	token.NULLISH:   COALESCE,
+	token.QUESTION:  TERNARY,
+	token.PIPELINE:  PIPELINE,
 }
 
 var BinaryOps = map[token.Type]string{
@@ -41,6 +50,12 @@ var BinaryOps = map[token.Type]string{
 	token.GTHAN:     "",
 	token.LTHAN:     "",
 	token.LPAREN:    "",
+	token.LBRACKET:  "",
+	token.DOT:       "",
+	token.QDOT:      "",
+	token.NULLISH:   "",
+	token.QUESTION:  "",
+	token.PIPELINE:  "",
 }
 
 var PrefixOps = map[token.Type]string{
@@ -55,14 +70,32 @@ type Parser struct {
 	cur  *token.Token
 	peek *token.Token
 
+	// depth is the number of enclosing statement blocks.
+	depth int
+
+	// nesting is the number of brackets of any kind open at cur, and
+	// ternaries holds the nesting of each ternary whose : is still to
+	// come. They tell which : a ? may pair with.
+	nesting   int
+	ternaries []int
+
+	// arrows caches whether the parenthesis at a position starts the
+	// parameter list of an arrow function.
+	arrows map[position]bool
+
+	// question caches whether the ? token question starts a ternary.
+	question        *token.Token
+	questionTernary bool
+
 	Errors []string
 }
 
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l}
+	p := &Parser{l: l, arrows: make(map[position]bool)}
 
 	p.cur = p.l.NextToken()
 	p.peek = p.l.NextToken()
+	p.count()
 
 	return p
 }
@@ -84,29 +117,60 @@ func (p *Parser) Parse() *ast.Program {
 }
 
 func (p *Parser) parseStatement() ast.Statement {
-	var stmt ast.Statement
-
+	// the parse functions return typed nil pointers on error, which must
+	// not leak out as non-nil statements
 	switch p.cur.Type {
 	case token.LET:
-		stmt = p.parseLetStmt()
+		if stmt := p.parseLetStmt(); stmt != nil {
+			return stmt
+		}
 	case token.RETURN:
-		stmt = p.parseReturnStmt()
+		if stmt := p.parseReturnStmt(); stmt != nil {
+			return stmt
+		}
+	case token.IMPORT:
+		if stmt := p.parseImportStmt(); stmt != nil {
+			return stmt
+		}
+	case token.EXPORT:
+		if stmt := p.parseExportStmt(); stmt != nil {
+			return stmt
+		}
+	case token.THROW:
+		if stmt := p.parseThrowStmt(); stmt != nil {
+			return stmt
+		}
+	case token.STRUCT:
+		if stmt := p.parseStructStmt(); stmt != nil {
+			return stmt
+		}
+	case token.ENUM:
+		if stmt := p.parseEnumStmt(); stmt != nil {
+			return stmt
+		}
+	case token.IMPL:
+		if stmt := p.parseImplStmt(); stmt != nil {
+			return stmt
+		}
 	default:
-		stmt = p.parseExprStatement()
+		if stmt := p.parseExprStatement(); stmt != nil {
+			return stmt
+		}
 	}
 
-	return stmt
+	return nil
 }
 
 func (p *Parser) parseLetStmt() *ast.LetStatement {
 	t := p.cur
 
-	if !p.nextIfPeek(token.IDENT) {
+	p.next()
+
+	pattern := p.parseBinding()
+	if pattern == nil {
 		return nil
 	}
 
-	ident := p.parseIdentExpr()
-
 	if !p.nextIfPeek(token.ASSIGN) {
 		return nil
 	}
@@ -125,11 +189,65 @@ func (p *Parser) parseLetStmt() *ast.LetStatement {
 
 	p.next()
 
+	// name functions after the variable they are bound to
+	if fn, ok := expr.(*ast.FunctionExpr); ok {
+		if ident, ok := pattern.(*ast.IdentExpr); ok {
+			fn.Name = ident.Value
+		}
+	}
+
 	return &ast.LetStatement{
-		Token: t,
-		Ident: ident,
-		Expr:  expr,
+		Token:   t,
+		Pattern: pattern,
+		Expr:    expr,
+	}
+}
+
+func (p *Parser) parseImportStmt() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.cur}
+
+	if !p.nextIfPeek(token.STRING) {
+		return nil
+	}
+	stmt.Path = p.parseStringExpr()
+
+	// as is only a keyword here, so it remains usable as a name
+	if p.peek.Type != token.IDENT || p.peek.Literal != "as" {
+		p.error("expected as after import path, but found %s", p.peek.Literal)
+		return nil
+	}
+	p.next()
+
+	if !p.nextIfPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Alias = p.parseIdentExpr()
+
+	if !p.nextIfPeek(token.SEMICOLON) {
+		return nil
 	}
+	p.next()
+
+	return stmt
+}
+
+func (p *Parser) parseExportStmt() *ast.ExportStatement {
+	stmt := &ast.ExportStatement{Token: p.cur}
+
+	if p.depth > 0 {
+		p.error("export is only allowed at the top level of a module")
+		return nil
+	}
+
+	if !p.nextIfPeek(token.LET) {
+		return nil
+	}
+
+	if stmt.Let = p.parseLetStmt(); stmt.Let == nil {
+		return nil
+	}
+
+	return stmt
 }
 
 func (p *Parser) parseReturnStmt() *ast.ReturnStatement {
@@ -153,7 +271,211 @@ func (p *Parser) parseReturnStmt() *ast.ReturnStatement {
 	}
 }
 
-func (p *Parser) parseExprStatement() *ast.ExpressionStatement {
+func (p *Parser) parseThrowStmt() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.cur}
+	p.next()
+
+	if stmt.Expr = p.parseExpr(LOWEST); stmt.Expr == nil {
+		return nil
+	}
+
+	if !p.nextIfPeek(token.SEMICOLON) {
+		return nil
+	}
+	p.next()
+
+	return stmt
+}
+
+func (p *Parser) parseStructStmt() *ast.StructStatement {
+	stmt := &ast.StructStatement{Token: p.cur}
+
+	if !p.nextIfPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = p.parseIdentExpr()
+
+	if !p.nextIfPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for p.peek.Type != token.RBRACE {
+		if !p.nextIfPeek(token.IDENT) {
+			return nil
+		}
+
+		field := p.parseIdentExpr()
+		if seen[field.Value] {
+			p.error("duplicate field %s in struct %s", field.Value, stmt.Name.Value)
+			return nil
+		}
+		seen[field.Value] = true
+		stmt.Fields = append(stmt.Fields, field)
+
+		if p.peek.Type != token.RBRACE && !p.nextIfPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.next()
+
+	if p.peek.Type == token.SEMICOLON {
+		p.next()
+	}
+	p.next()
+
+	return stmt
+}
+
+func (p *Parser) parseEnumStmt() *ast.EnumStatement {
+	stmt := &ast.EnumStatement{Token: p.cur}
+
+	if !p.nextIfPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = p.parseIdentExpr()
+
+	if !p.nextIfPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for p.peek.Type != token.RBRACE {
+		if !p.nextIfPeek(token.IDENT) {
+			return nil
+		}
+
+		variant := &ast.EnumVariant{Name: p.parseIdentExpr()}
+		if seen[variant.Name.Value] {
+			p.error("duplicate variant %s in enum %s", variant.Name.Value, stmt.Name.Value)
+			return nil
+		}
+		seen[variant.Name.Value] = true
+
+		if p.peek.Type == token.LPAREN {
+			p.next()
+			if variant.Fields = p.parseFieldList(stmt.Name.Value + "." + variant.Name.Value); variant.Fields == nil {
+				return nil
+			}
+		}
+		stmt.Variants = append(stmt.Variants, variant)
+
+		if p.peek.Type != token.RBRACE && !p.nextIfPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.next()
+
+	if p.peek.Type == token.SEMICOLON {
+		p.next()
+	}
+	p.next()
+
+	return stmt
+}
+
+// parseFieldList parses the field names of the variant called name, up to
+// the closing parenthesis. It returns an empty list for () and nil on error.
+func (p *Parser) parseFieldList(name string) []*ast.IdentExpr {
+	fields := []*ast.IdentExpr{}
+
+	seen := map[string]bool{}
+	for p.peek.Type != token.RPAREN {
+		if !p.nextIfPeek(token.IDENT) {
+			return nil
+		}
+
+		field := p.parseIdentExpr()
+		if seen[field.Value] {
+			p.error("duplicate field %s in %s", field.Value, name)
+			return nil
+		}
+		seen[field.Value] = true
+		fields = append(fields, field)
+
+		if p.peek.Type != token.RPAREN && !p.nextIfPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.next()
+
+	return fields
+}
+
+func (p *Parser) parseImplStmt() *ast.ImplStatement {
+	stmt := &ast.ImplStatement{Token: p.cur}
+
+	if !p.nextIfPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Type = p.parseIdentExpr()
+
+	if !p.nextIfPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for p.peek.Type != token.RBRACE {
+		if !p.nextIfPeek(token.FUNCTION) {
+			return nil
+		}
+
+		method := p.parseMethod(stmt.Type.Value)
+		if method == nil {
+			return nil
+		}
+
+		if seen[method.Name] {
+			p.error("duplicate method %s in impl %s", method.Name, stmt.Type.Value)
+			return nil
+		}
+		seen[method.Name] = true
+		stmt.Methods = append(stmt.Methods, method)
+	}
+	p.next()
+
+	if p.peek.Type == token.SEMICOLON {
+		p.next()
+	}
+	p.next()
+
+	return stmt
+}
+
+// parseMethod parses fn name(self, ...) { body } in an impl block for the
+// type typeName.
+func (p *Parser) parseMethod(typeName string) *ast.FunctionExpr {
+	expr := &ast.FunctionExpr{Token: p.cur}
+
+	if !p.nextIfPeek(token.IDENT) {
+		return nil
+	}
+	expr.Name = p.cur.Literal
+
+	if !p.nextIfPeek(token.LPAREN) {
+		return nil
+	}
+
+	if expr.Parameters = p.parseParamList(); expr.Parameters == nil {
+		return nil
+	}
+
+	if len(expr.Parameters) == 0 || expr.Parameters[0].ToString() != "self" {
+		p.error("method %s of %s must take self as its first parameter", expr.Name, typeName)
+		return nil
+	}
+
+	if !p.nextIfPeek(token.LBRACE) {
+		return nil
+	}
+	expr.Body = p.parseStmtBlock()
+
+	return expr
+}
+
+// parseExprStatement parses an expression statement, or an assignment if
+// the expression is followed by =.
+func (p *Parser) parseExprStatement() ast.Statement {
 	stmt := &ast.ExpressionStatement{
 		Token: p.cur,
 	}
@@ -163,6 +485,13 @@ func (p *Parser) parseExprStatement() *ast.ExpressionStatement {
 		return nil
 	}
 
+	if p.peek.Type == token.ASSIGN {
+		if assign := p.parseAssignStmt(stmt.Expr); assign != nil {
+			return assign
+		}
+		return nil
+	}
+
 	if p.peek.Type == token.SEMICOLON {
 		p.next()
 	}
@@ -172,19 +501,47 @@ func (p *Parser) parseExprStatement() *ast.ExpressionStatement {
 	return stmt
 }
 
+func (p *Parser) parseAssignStmt(target ast.Expression) *ast.AssignStatement {
+	member, ok := target.(*ast.MemberExpr)
+	if !ok || member.Optional {
+		p.error("cannot assign to %s", target.ToString())
+		return nil
+	}
+
+	p.next()
+	stmt := &ast.AssignStatement{Token: p.cur, Target: member}
+	p.next()
+
+	if stmt.Value = p.parseExpr(LOWEST); stmt.Value == nil {
+		return nil
+	}
+
+	if !p.nextIfPeek(token.SEMICOLON) {
+		return nil
+	}
+	p.next()
+
+	return stmt
+}
+
 func (p *Parser) parseStmtBlock() *ast.StatementBlock {
 	block := &ast.StatementBlock{
 		Token: p.cur,
 	}
 
+	p.depth++
+	defer func() { p.depth-- }()
+
 	p.next()
 
 	for p.cur.Type != token.RBRACE && p.cur.Type != token.EOF {
 		stmt := p.parseStatement()
 
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
+		if stmt == nil {
+			break
 		}
+
+		block.Statements = append(block.Statements, stmt)
 	}
 
 	return block
@@ -193,11 +550,22 @@ func (p *Parser) parseStmtBlock() *ast.StatementBlock {
 func (p *Parser) next() {
 	p.cur = p.peek
 	p.peek = p.l.NextToken()
+	p.count()
+}
+
+// count updates nesting for the bracket at cur, if any.
+func (p *Parser) count() {
+	switch p.cur.Type {
+	case token.LPAREN, token.LBRACKET, token.LBRACE:
+		p.nesting++
+	case token.RPAREN, token.RBRACKET, token.RBRACE:
+		p.nesting--
+	}
 }
 
 func (p *Parser) nextIfPeek(t token.Type) bool {
 	if p.peek.Type != t {
-		p.error("expected %s, but found %s", ")", p.peek.Literal)
+		p.error("expected %s, but found %s", t, p.peek.Literal)
 		return false
 	}
 	p.next()
@@ -212,6 +580,14 @@ func (p *Parser) curPrecedence() int {
 }
 
 func (p *Parser) peekPrecedence() int {
+	// the postfix ? binds as tightly as a call
+	if p.peek.Type == token.QUESTION {
+		scan := *p.l
+		if !p.ternaryAhead(p.peek, scan.NextToken().Type, scan) {
+			return CALL
+		}
+	}
+
 	if pr, ok := TokenPrecedence[p.peek.Type]; ok {
 		return pr
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/slinky55/milo/ast"
 	"github.com/slinky55/milo/lexer"
+	"strings"
 	"testing"
 )
 
@@ -29,8 +30,8 @@ func TestLetStatements(t *testing.T) {
 			continue
 		}
 
-		if let.Ident.Literal() != idents[i] {
-			p.error(fmt.Sprintf("expected ident %s, found %s", idents[i], let.Ident.Literal()))
+		if let.Pattern.Literal() != idents[i] {
+			p.error(fmt.Sprintf("expected ident %s, found %s", idents[i], let.Pattern.Literal()))
 		}
 
 		num, ok := let.Expr.(*ast.NumberExpr)
@@ -417,3 +418,706 @@ func TestCallExpressions(t *testing.T) {
 	}
 
 }
+
+func TestCollectionExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a[0]", "a[0]"},
+		{"a[i + 1]", "a[(i + 1)]"},
+		{"a[0][1]", "a[0][1]"},
+		{"[1, \"two\", x]", "[1, two, x]"},
+		{"[]", "[]"},
+		{"{name: 1, \"k\": 2, 3: x}", "{name: 1, k: 2, 3: x}"},
+		{"{}", "{}"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("parser had errors: %v", p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestMemberExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a.b", "a.b"},
+		{"a.b.c(1)", "a.b.c(1)"},
+		{"-a.b", "(-a.b)"},
+		{"let m = {a: [1, 2]}; m.a[1]", "let m = {a: [1, 2]}; m.a[1]"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("parser had errors: %v", p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestNullSafeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"null", "null"},
+		{"a ?? b", "(a ?? b)"},
+		{"a ?? b == c", "(a ?? (b == c))"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a?.b.c", "a?.b.c"},
+		{"a?.b(1)?.c", "a?.b(1)?.c"},
+		{"a?.[i + 1]", "a?.[(i + 1)]"},
+		{"[1, \"two\", null]", "[1, two, null]"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("parser had errors: %v", p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestConditionalExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a ? b : c", "(a ? b : c)"},
+		{"a ? b : c ? d : e", "(a ? b : (c ? d : e))"},
+		{"x > 1 ? x + 1 : -x", "((x > 1) ? (x + 1) : (-x))"},
+		{"a ?? b ? c : d", "((a ?? b) ? c : d)"},
+		{"match (x) { 1 => a, _ => b }", "match (x) { 1 => a, _ => b }"},
+		{"match (x) { \"a\" | \"b\" => 1, -1 => 2 }", "match (x) { a | b => 1, (-1) => 2 }"},
+		{"match (n) { 0..9 => { n } }", "match (n) { 0..9 => { n } }"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestBindingPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b, ...rest] = arr;", "let [a, b, ...rest] = arr;"},
+		{"let [] = arr;", "let [] = arr;"},
+		{"let {name, age} = person;", "let {name, age} = person;"},
+		{"let {name: n, age = 1 + 1} = person;", "let {name: n, age = (1 + 1)} = person;"},
+		{"let {address: {city, zip: [z = 0]}} = person;", "let {address: {city, zip: [z = 0]}} = person;"},
+		{"let [x, y = x * 2] = p;", "let [x, y = (x * 2)] = p;"},
+		{"fn ([a, b], {c}, d = 1) { a }", "fn ([a, b], {c}, d = 1) { a }"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestBindingErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [...rest, a] = arr;", "parser error: rest element must be last in [...rest]"},
+		{"let 5 = x;", "parser error: unexpected 5 in binding"},
+		{"let {\"a\"} = x;", "parser error: expected IDENT, but found a"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		p.Parse()
+
+		if len(p.Errors) == 0 || p.Errors[0] != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, p.Errors)
+		}
+	}
+}
+
+func TestParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn() {}", "fn () {  }"},
+		{"fn(x, y = 10) { x }", "fn (x, y = 10) { x }"},
+		{"fn(first, ...rest) { rest }", "fn (first, ...rest) { rest }"},
+		{"fn(...all) { all }", "fn (...all) { all }"},
+		{"f()", "f()"},
+		{"f(1, y: 2)", "f(1, y: 2)"},
+		{"f(x: a ? b : c, y: {k: 1})", "f(x: (a ? b : c), y: {k: 1})"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestParameterErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(...rest, x) {}", "parser error: rest parameter ...rest must be last"},
+		{"f(x: 1, 2)", "parser error: positional argument after named argument in call to f"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		p.Parse()
+
+		if len(p.Errors) == 0 || p.Errors[0] != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, p.Errors)
+		}
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x => x * 2", "fn (x) { (x * 2) }"},
+		{"(x) => x * 2", "fn (x) { (x * 2) }"},
+		{"(a, b = 1) => { let c = a; c + b }", "fn (a, b = 1) { let c = a;(c + b) }"},
+		{"() => 1", "fn () { 1 }"},
+		{"([a, b], {c}) => a", "fn ([a, b], {c}) { a }"},
+		{"f(x => x + 1, 2)", "f(fn (x) { (x + 1) }, 2)"},
+		{"x => y => x + y", "fn (x) { fn (y) { (x + y) } }"},
+		{"(a + b) * c", "((a + b) * c)"},
+		{"(f(a)) (b)", "f(a)(b)"},
+		{"arr.map(x => x * 2).filter(even)", "arr.map(fn (x) { (x * 2) }).filter(even)"},
+		{"((a) => a)((b, c) => (b))", "fn (a) { a }(fn (b, c) { b })"},
+		{"((x) + (f((y) => y)))", "(x + f(fn (y) { y }))"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestPipelines(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x |> f", "(x |> f)"},
+		{"data |> parse |> filter(isValid) |> len", "(((data |> parse) |> filter(isValid)) |> len)"},
+		{"a + 1 |> f", "((a + 1) |> f)"},
+		{"a ?? b |> f", "((a ?? b) |> f)"},
+		{"c ? x |> f : y", "(c ? (x |> f) : y)"},
+		{"x |> (y => y * 2)", "(x |> fn (y) { (y * 2) })"},
+		{"x |> m.f(1)", "(x |> m.f(1))"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestModuleStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"import \"utils.milo\" as u;", "import \"utils.milo\" as u;"},
+		{"export let x = 1;", "export let x = 1;"},
+		{"export let [a, b] = pair;", "export let [a, b] = pair;"},
+		{"let as = 1;", "let as = 1;"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"import utils as u;", "parser error: expected STRING, but found utils"},
+		{"import \"utils.milo\";", "parser error: expected as after import path, but found ;"},
+		{"export 1;", "parser error: expected LET, but found 1"},
+		{"fn() { export let x = 1; }", "parser error: export is only allowed at the top level of a module"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		p.Parse()
+
+		if len(p.Errors) == 0 || p.Errors[0] != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, p.Errors)
+		}
+	}
+}
+
+func TestExceptionStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"throw \"bad\";", "throw bad;"},
+		{"throw error(\"bad\", \"Kind\");", "throw error(bad, Kind);"},
+		{"try { f() } catch (e) { e.message }", "try { f() } catch (e) { e.message }"},
+		{"try { f() } finally { g() }", "try { f() } finally { g() }"},
+		{"let x = try { 1 } catch (e) { 2 } finally { 3 };", "let x = try { 1 } catch (e) { 2 } finally { 3 };"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestExceptionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"throw \"bad\"", "parser error: expected SEMICOLON, but found "},
+		{"try { f() }", "parser error: expected catch or finally after try block, but found "},
+		{"try { f() } catch { g() }", "parser error: expected LPAREN, but found {"},
+		{"try { f() } catch (1) { g() }", "parser error: expected IDENT, but found 1"},
+		{"try f() catch (e) { g() }", "parser error: expected LBRACE, but found f"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		p.Parse()
+
+		if len(p.Errors) == 0 || p.Errors[0] != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, p.Errors)
+		}
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"parse(x)?", "(parse(x)?)"},
+		{"let n = parse(x)?;", "let n = (parse(x)?);"},
+		{"a + f()? * 2", "(a + ((f()?) * 2))"},
+		{"!check()?", "(!(check()?))"},
+		{"r.value?", "(r.value?)"},
+		{"[f()?, g(h()?)]", "[(f()?), g((h()?))]"},
+		{"f()? |> g", "((f()?) |> g)"},
+		{"f()? ? 1 : 2", "((f()?) ? 1 : 2)"},
+		{"c ? x : y", "(c ? x : y)"},
+		{"c ? -1 : 1", "(c ? (-1) : 1)"},
+		{"c ? [x] : {}", "(c ? [x] : {})"},
+		{"x? - 1", "((x?) - 1)"},
+		{"let v = g()? - 1;", "let v = ((g()?) - 1);"},
+		{"x? (y)", "(x?)(y)"},
+		{"x? [0]", "(x?)[0]"},
+		{"x? - 1 ? a : b", "(((x?) - 1) ? a : b)"},
+		{"c ? x? - 1 : 2", "(c ? ((x?) - 1) : 2)"},
+		{"c ? d ? -1 : 2 : 3", "(c ? (d ? (-1) : 2) : 3)"},
+		{"c ? (x? - 1) : (y)", "(c ? ((x?) - 1) : y)"},
+		{"f(x? - 1, c ? (a) : b)", "f(((x?) - 1), (c ? a : b))"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestStructStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }", "struct Point { x, y }"},
+		{"struct Point { x, y, };", "struct Point { x, y }"},
+		{"struct Unit {} Unit()", "struct Unit {  } Unit()"},
+		{"p.x = 3;", "p.x = 3;"},
+		{"a.b.c = f(1) + 2;", "a.b.c = (f(1) + 2);"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct { x }", "parser error: expected IDENT, but found {"},
+		{"struct Point { x, x }", "parser error: duplicate field x in struct Point"},
+		{"struct Point { x y }", "parser error: expected COMMA, but found y"},
+		{"x = 1;", "parser error: cannot assign to x"},
+		{"p?.x = 1;", "parser error: cannot assign to p?.x"},
+		{"p.x = 1", "parser error: expected SEMICOLON, but found "},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		p.Parse()
+
+		if len(p.Errors) == 0 || p.Errors[0] != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, p.Errors)
+		}
+	}
+}
+
+func TestImplStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"impl Point { fn dist(self, other) { self.x - other.x } }", "impl Point { fn dist(self, other) { (self.x - other.x) } }"},
+		{"impl Point { fn a(self) { 1 } fn b(self, k = 2) { k } };", "impl Point { fn a(self) { 1 } fn b(self, k = 2) { k } }"},
+		{"impl Unit {}", "impl Unit { }"},
+		{"a.b.c(1) + d.e", "(a.b.c(1) + d.e)"},
+		{"p.dist(q).x", "p.dist(q).x"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestImplErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"impl { }", "parser error: expected IDENT, but found {"},
+		{"impl Point { let x = 1; }", "parser error: expected FUNCTION, but found let"},
+		{"impl Point { fn (self) { 1 } }", "parser error: expected IDENT, but found ("},
+		{"impl Point { fn f() { 1 } }", "parser error: method f of Point must take self as its first parameter"},
+		{"impl Point { fn f(other) { 1 } }", "parser error: method f of Point must take self as its first parameter"},
+		{"impl Point { fn f(self) { 1 } fn f(self) { 2 } }", "parser error: duplicate method f in impl Point"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		p.Parse()
+
+		if len(p.Errors) == 0 || p.Errors[0] != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, p.Errors)
+		}
+	}
+}
+
+func TestEnumStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum Shape { Circle(r), Rect(w, h), Empty }", "enum Shape { Circle(r), Rect(w, h), Empty }"},
+		{"enum Token { Eof, Unit(), };", "enum Token { Eof, Unit() }"},
+		{"match (s) { Shape.Circle(r) => r, Shape.Empty => 0 }", "match (s) { Shape.Circle(r) => r, Shape.Empty => 0 }"},
+		{"match (s) { Shape.Rect(w, 1) | Shape.Rect(1, w) => w, _ => 0 }", "match (s) { Shape.Rect(w, 1) | Shape.Rect(1, w) => w, _ => 0 }"},
+		{"match (t) { Tree.Node(Tree.Leaf, _, right) => right }", "match (t) { Tree.Node(Tree.Leaf, _, right) => right }"},
+		{"match (x) { Opt.Some(1..3) => 1 }", "match (x) { Opt.Some(1..3) => 1 }"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+
+		program := p.Parse()
+
+		if len(p.Errors) > 0 {
+			t.Errorf("%s: parser had errors: %v", test.input, p.Errors)
+			continue
+		}
+
+		var stmts []string
+		for _, stmt := range program.Statements {
+			stmts = append(stmts, stmt.ToString())
+		}
+
+		actual := strings.Join(stmts, " ")
+		if actual != test.expected {
+			t.Errorf("expected %s, found %s", test.expected, actual)
+		}
+	}
+}
+
+func TestEnumErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum { A }", "parser error: expected IDENT, but found {"},
+		{"enum Shape { A, A }", "parser error: duplicate variant A in enum Shape"},
+		{"enum Shape { Rect(w, w) }", "parser error: duplicate field w in Shape.Rect"},
+		{"enum Shape { Rect(1) }", "parser error: expected IDENT, but found 1"},
+		{"enum Shape { A B }", "parser error: expected COMMA, but found B"},
+		{"match (s) { Shape.(r) => r }", "parser error: expected IDENT, but found ("},
+		{"match (s) { Shape.Circle(r s) => r }", "parser error: expected COMMA, but found s"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		p.Parse()
+
+		if len(p.Errors) == 0 || p.Errors[0] != test.expected {
+			t.Errorf("%s: expected error %q, found %v", test.input, test.expected, p.Errors)
+		}
+	}
+}
+
+func TestNestedParenthesesScannedOnce(t *testing.T) {
+	const depth = 1000
+	input := strings.Repeat("(", depth) + "x" + strings.Repeat(")", depth)
+
+	p := New(lexer.New(input))
+	program := p.Parse()
+
+	if len(p.Errors) > 0 {
+		t.Fatalf("parser had errors: %v", p.Errors)
+	}
+	if actual := program.Statements[0].ToString(); actual != "x" {
+		t.Errorf("expected x, found %s", actual)
+	}
+
+	// the first scan decides every parenthesis
+	if len(p.arrows) != depth {
+		t.Errorf("expected %d parentheses decided, found %d", depth, len(p.arrows))
+	}
+}
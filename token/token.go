@@ -31,6 +31,26 @@ const (
 
 	NULL = "NULL"
 
+	MATCH = "MATCH"
+
+	IMPORT = "IMPORT"
+
+	EXPORT = "EXPORT"
+
+	THROW = "THROW"
+
+	TRY = "TRY"
+
+	CATCH = "CATCH"
+
+	FINALLY = "FINALLY"
+
+	STRUCT = "STRUCT"
+
+	IMPL = "IMPL"
+
+	ENUM = "ENUM"
+
 	ASSIGN = "ASSIGN"
 
 	PLUS = "PLUS"
@@ -57,6 +77,30 @@ const (
 
 	RBRACE = "RBRACE"
 
+	LBRACKET = "LBRACKET"
+
+	RBRACKET = "RBRACKET"
+
+	COLON = "COLON"
+
+	DOT = "DOT"
+
+	QDOT = "QDOT"
+
+	NULLISH = "NULLISH"
+
+	QUESTION = "QUESTION"
+
+	PIPE = "PIPE"
+
+	PIPELINE = "PIPELINE"
+
+	ARROW = "ARROW"
+
+	DOTDOT = "DOTDOT"
+
+	ELLIPSIS = "ELLIPSIS"
+
 	BANG = "BANG"
 
 	LTHAN = "LESS THEN"
@@ -69,20 +113,35 @@ const (
 )
 
 var ReservedWords = map[string]Type{
-	"let":    LET,
-	"var":    VAR,
-	"return": RETURN,
-	"fn":     FUNCTION,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"null":   NULL,
+	"let":     LET,
+	"var":     VAR,
+	"return":  RETURN,
+	"fn":      FUNCTION,
+	"true":    TRUE,
+	"false":   FALSE,
+	"if":      IF,
+	"else":    ELSE,
+	"null":    NULL,
+	"match":   MATCH,
+	"import":  IMPORT,
+	"export":  EXPORT,
+	"throw":   THROW,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"struct":  STRUCT,
+	"impl":    IMPL,
+	"enum":    ENUM,
 }
 
 type Token struct {
 	Type    Type
 	Literal string
+
+	// Line and Column locate the first character of the token, starting
+	// at 1. They are zero for tokens not read from source.
+	Line   int
+	Column int
 }
 
 func New(t Type, lit string) *Token {